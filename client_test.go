@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	client, e := NewClient(
+		[]string{"10.16.15.121:9731", "10.16.15.121:9991:1234567890", "10.16.15.121:9731"},
+		WithMaxConnPerServer(10),
+		WithMaxIdleConnPerServer(5),
+		WithMaxIdleSecondsPerServer(30),
+		WithTimeout(time.Second, 2*time.Second, 3*time.Second),
+		WithRetryTimes(3),
+		WithReadPolicy(ReadFromSlave),
+		WithSlaves("10.16.15.121:9731", "10.16.15.121:9732", "10.16.15.121:9733:pass"),
+	)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if servers := client.Servers(); len(servers) != 2 || servers[0] != "10.16.15.121:9731" || servers[1] != "10.16.15.121:9991" {
+		t.Fatalf("servers=%v", servers)
+	}
+
+	cd := client.Driver("")
+	if cd != client.Driver("10.16.15.121:9731") {
+		t.Fatal("empty addr should get the first driver")
+	}
+	if cd.retry != 3 {
+		t.Errorf("retry=%d", cd.retry)
+	}
+	if cd.mp.MaxConnNum != 10 || cd.mp.MaxIdleNum != 5 || cd.mp.MaxIdleSeconds != 30 {
+		t.Errorf("master pool=%+v", cd.mp)
+	}
+	if len(cd.sp) != 2 || cd.sp[1].Password != "pass" {
+		t.Fatalf("slave pools=%v", cd.sp)
+	}
+	if cd.pool("SET") != cd.mp {
+		t.Error("write command should use master")
+	}
+	if p := cd.pool("GET"); p != cd.sp[0] && p != cd.sp[1] {
+		t.Error("read command should use slave")
+	}
+	if pw := client.Driver("10.16.15.121:9991").mp.Password; pw != "1234567890" {
+		t.Errorf("password=%s", pw)
+	}
+
+	if _, e := NewClient(nil); e != ErrNoServer {
+		t.Errorf("e=%v", e)
+	}
+	if _, e := NewClient([]string{"10.16.15.121"}); e == nil {
+		t.Error("invalid address should fail")
+	}
+	if _, e := NewClient([]string{"redis://:secret@10.16.15.121/db"}); e == nil || strings.Contains(e.Error(), "secret") {
+		t.Errorf("e=%v", e)
+	}
+	if _, e := NewClient([]string{"redis://:secret@10.16.15.121:9731/1", "redis://10.16.15.121:9731/2"}); e == nil || strings.Contains(e.Error(), "secret") {
+		t.Errorf("same server with another db e=%v", e)
+	}
+	if _, e := NewClient([]string{"redis://10.16.15.121:9731/0", "10.16.15.121:9731"}, WithDB(0)); e != nil {
+		t.Errorf("same server and db e=%v", e)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	s1, s2 := newFakeServer(t, nil), newFakeServer(t, nil)
+	client, _ := NewClient([]string{s1.Addr(), s2.Addr()})
+	for _, cd := range client.Drivers() {
+		if _, e := cd.Call("SET", "a", "1"); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e := client.Close(); e != nil {
+		t.Fatal(e)
+	}
+	if s1.Count("QUIT") != 1 || s2.Count("QUIT") != 1 {
+		t.Errorf("QUIT=%d,%d", s1.Count("QUIT"), s2.Count("QUIT"))
+	}
+	if _, e := client.Driver("").Call("GET", "a"); e != ErrPoolClosed {
+		t.Errorf("call after closed e=%v", e)
+	}
+
+	// the deprecated QUIT closes the driver
+	client, _ = NewClient([]string{s1.Addr()})
+	cd := client.Driver("")
+	cd.Call("SET", "a", "1")
+	cd.QUIT()
+	if _, e := cd.Call("GET", "a"); e != ErrPoolClosed || s1.Count("QUIT") != 2 {
+		t.Errorf("call after QUIT e=%v", e)
+	}
+}
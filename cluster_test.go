@@ -0,0 +1,376 @@
+package redis
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fake redis cluster of fake servers, redirecting the keys not served
+type fakeCluster struct {
+	servers []*fakeServer
+
+	mu     sync.Mutex
+	owner  [ClusterSlots]int // index of the server serving the slot
+	ask    map[int]int       // slot => index of the server importing it
+	shards bool              // answer CLUSTER SHARDS only
+	loop   bool              // MOVED to the next server forever
+}
+
+func newFakeCluster(t *testing.T, n int) *fakeCluster {
+	fc := &fakeCluster{ask: make(map[int]int)}
+	for i := 0; i < n; i++ {
+		fc.servers = append(fc.servers, newFakeServer(t, fc.handler(i)))
+	}
+	for slot := range fc.owner {
+		fc.owner[slot] = slot * n / ClusterSlots
+	}
+	return fc
+}
+
+func (fc *fakeCluster) handler(i int) func(sess *fakeSession, args []string) string {
+	return func(sess *fakeSession, args []string) string {
+		command := strings.ToUpper(args[0])
+		asking := sess.asking
+		sess.asking = false
+		switch command {
+		case "ASKING":
+			sess.asking = true
+			return simpleReply("OK")
+		case "CLUSTER":
+			fc.mu.Lock()
+			defer fc.mu.Unlock()
+			switch strings.ToUpper(args[1]) {
+			case "SLOTS":
+				if !fc.shards {
+					return fc.slotsReply()
+				}
+			case "SHARDS":
+				return fc.shardsReply()
+			}
+			return errReply("ERR unknown subcommand '" + args[1] + "'")
+		case "GET", "SET", "DEL", "INCR":
+		case "RENAME":
+			return errReply("TRYAGAIN Multiple keys request during rehashing of slot")
+		default:
+			return fakeRedis(sess, args)
+		}
+
+		slot := Slot(args[1])
+		fc.mu.Lock()
+		owner, loop := fc.owner[slot], fc.loop
+		target, migrating := fc.ask[slot]
+		fc.mu.Unlock()
+		switch {
+		case loop:
+			return errReply("MOVED " + strconv.Itoa(slot) + " " + fc.servers[(i+1)%len(fc.servers)].Addr())
+		case owner == i && migrating:
+			sess.server.dmu.Lock()
+			_, ok := sess.server.DB(0)[args[1]]
+			sess.server.dmu.Unlock()
+			if !ok {
+				return errReply("ASK " + strconv.Itoa(slot) + " " + fc.servers[target].Addr())
+			}
+		case owner != i && !(asking && migrating && target == i):
+			return errReply("MOVED " + strconv.Itoa(slot) + " " + fc.servers[owner].Addr())
+		}
+		return fakeRedis(sess, args)
+	}
+}
+
+// contiguous slots served by the same server
+func (fc *fakeCluster) ranges(fn func(start, end, owner int)) {
+	start := 0
+	for slot := 1; slot <= ClusterSlots; slot++ {
+		if slot == ClusterSlots || fc.owner[slot] != fc.owner[start] {
+			fn(start, slot-1, fc.owner[start])
+			start = slot
+		}
+	}
+}
+
+func hostPort(addr string) (string, int64) {
+	host, port, _ := net.SplitHostPort(addr)
+	n, _ := strconv.ParseInt(port, 10, 64)
+	return host, n
+}
+
+func (fc *fakeCluster) slotsReply() string {
+	var replies []string
+	fc.ranges(func(start, end, owner int) {
+		host, port := hostPort(fc.servers[owner].Addr())
+		node := arrayReply(bulkReply(host), intReply(port), bulkReply("node"+strconv.Itoa(owner)))
+		replies = append(replies, arrayReply(intReply(int64(start)), intReply(int64(end)), node))
+	})
+	return arrayReply(replies...)
+}
+
+func (fc *fakeCluster) shardsReply() string {
+	slots := make([][]string, len(fc.servers))
+	fc.ranges(func(start, end, owner int) {
+		slots[owner] = append(slots[owner], intReply(int64(start)), intReply(int64(end)))
+	})
+	var replies []string
+	for i, s := range fc.servers {
+		host, port := hostPort(s.Addr())
+		node := arrayReply(bulkReply("id"), bulkReply("node"+strconv.Itoa(i)), bulkReply("port"), intReply(port),
+			bulkReply("ip"), bulkReply(host), bulkReply("endpoint"), bulkReply(host),
+			bulkReply("role"), bulkReply("master"), bulkReply("health"), bulkReply("online"))
+		replica := arrayReply(bulkReply("port"), intReply(1), bulkReply("ip"), bulkReply(host), bulkReply("role"), bulkReply("replica"))
+		replies = append(replies, arrayReply(bulkReply("slots"), arrayReply(slots[i]...),
+			bulkReply("nodes"), arrayReply(replica, node)))
+	}
+	return arrayReply(replies...)
+}
+
+// move the slot to server to with its keys
+func (fc *fakeCluster) migrate(slot, to int) {
+	fc.mu.Lock()
+	from := fc.servers[fc.owner[slot]]
+	fc.owner[slot] = to
+	delete(fc.ask, slot)
+	fc.mu.Unlock()
+	fc.moveKeys(from, fc.servers[to], func(key string) bool { return Slot(key) == slot })
+}
+
+func (fc *fakeCluster) moveKeys(from, to *fakeServer, match func(key string) bool) {
+	from.dmu.Lock()
+	to.dmu.Lock()
+	for key, v := range from.DB(0) {
+		if match(key) {
+			to.DB(0)[key] = v
+			delete(from.DB(0), key)
+		}
+	}
+	to.dmu.Unlock()
+	from.dmu.Unlock()
+}
+
+func TestClusterRouting(t *testing.T) {
+	fc := newFakeCluster(t, 3)
+	cc, e := NewClusterClient([]string{fc.servers[1].Addr()})
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer cc.Close()
+
+	if Slot("foo") != 12182 || Slot("user:{42}:profile") != Slot("user:{42}:cart") {
+		t.Errorf("slot of foo=%d", Slot("foo"))
+	}
+	if len(cc.Nodes()) != 3 {
+		t.Fatalf("nodes=%v", cc.Nodes())
+	}
+	for i := 0; i < 100; i++ {
+		key := "key:" + strconv.Itoa(i)
+		if _, e := cc.Call("SET", key, i); e != nil {
+			t.Fatal(e)
+		}
+		if v, e := cc.Call("GET", key); e != nil || string(v.([]byte)) != strconv.Itoa(i) {
+			t.Fatalf("GET %s=%v e=%v", key, v, e)
+		}
+	}
+	for i, s := range fc.servers {
+		if len(s.DB(0)) == 0 {
+			t.Errorf("no key on server %d", i)
+		}
+		for key := range s.DB(0) {
+			if fc.owner[Slot(key)] != i || cc.NodeByKey(key) != s.Addr() {
+				t.Errorf("%s on server %d", key, i)
+			}
+		}
+		// no redirect with the slots loaded
+		if n := s.Count("SET"); n != len(s.DB(0)) {
+			t.Errorf("server %d SET %d times for %d keys", i, n, len(s.DB(0)))
+		}
+	}
+	if v, e := cc.Call("PING"); e != nil || string(v.([]byte)) != "PONG" {
+		t.Errorf("PING=%s e=%v", v, e)
+	}
+
+	// the key not a string is routed as written
+	sets := 0
+	for _, s := range fc.servers {
+		sets += s.Count("SET")
+	}
+	if _, e := cc.Call("SET", 42, "v"); e != nil {
+		t.Fatal(e)
+	}
+	owner := fc.servers[fc.owner[Slot("42")]]
+	if owner.DB(0)["42"] != "v" {
+		t.Error("42 not on the owner of its slot")
+	}
+	for _, s := range fc.servers {
+		sets -= s.Count("SET")
+	}
+	if sets != -1 {
+		t.Errorf("SET %d times, redirected", -sets)
+	}
+}
+
+func TestClusterMoved(t *testing.T) {
+	fc := newFakeCluster(t, 3)
+	cc, e := NewClusterClient([]string{fc.servers[0].Addr()}, WithClusterRefresh(0))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer cc.Close()
+
+	key := "foo"
+	slot := Slot(key)
+	if _, e := cc.Call("SET", key, "bar"); e != nil {
+		t.Fatal(e)
+	}
+	from := fc.owner[slot]
+	to := (from + 1) % 3
+	fc.migrate(slot, to)
+	refreshed := fc.servers[0].Count("CLUSTER") + fc.servers[1].Count("CLUSTER") + fc.servers[2].Count("CLUSTER")
+
+	if v, e := cc.Call("GET", key); e != nil || string(v.([]byte)) != "bar" {
+		t.Fatalf("GET=%v e=%v", v, e)
+	}
+	if cc.NodeByKey(key) != fc.servers[to].Addr() {
+		t.Errorf("slot not updated by MOVED, node=%s", cc.NodeByKey(key))
+	}
+	if n := fc.servers[from].Count("GET"); n != 1 {
+		t.Errorf("GET on old node %d times", n)
+	}
+	// the slots are reloaded in background
+	deadline := time.Now().Add(time.Second)
+	for fc.servers[0].Count("CLUSTER")+fc.servers[1].Count("CLUSTER")+fc.servers[2].Count("CLUSTER") == refreshed {
+		if time.Now().After(deadline) {
+			t.Fatal("slots not reloaded after MOVED")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// no more redirect
+	cc.Call("GET", key)
+	if n := fc.servers[from].Count("GET"); n != 1 {
+		t.Errorf("GET on old node %d times", n)
+	}
+}
+
+func TestClusterAsk(t *testing.T) {
+	fc := newFakeCluster(t, 2)
+	cc, e := NewClusterClient([]string{fc.servers[0].Addr()}, WithClusterRefresh(0))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer cc.Close()
+
+	key := "foo"
+	slot := Slot(key)
+	if _, e := cc.Call("SET", key, "bar"); e != nil {
+		t.Fatal(e)
+	}
+	from := fc.owner[slot]
+	to := 1 - from
+	// the key is migrated and the slot is not
+	fc.mu.Lock()
+	fc.ask[slot] = to
+	fc.mu.Unlock()
+	fc.moveKeys(fc.servers[from], fc.servers[to], func(k string) bool { return k == key })
+
+	for i := 1; i <= 2; i++ {
+		if v, e := cc.Call("GET", key); e != nil || string(v.([]byte)) != "bar" {
+			t.Fatalf("GET=%v e=%v", v, e)
+		}
+		if n := fc.servers[to].Count("ASKING"); n != i {
+			t.Errorf("ASKING %d times", n)
+		}
+		if cc.NodeByKey(key) != fc.servers[from].Addr() {
+			t.Errorf("slot updated by ASK")
+		}
+	}
+}
+
+func TestClusterRefresh(t *testing.T) {
+	fc := newFakeCluster(t, 3)
+	cc, e := NewClusterClient([]string{fc.servers[0].Addr()}, WithClusterRefresh(20*time.Millisecond))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer cc.Close()
+
+	// server 2 leaves the cluster
+	fc.mu.Lock()
+	for slot, owner := range fc.owner {
+		if owner == 2 {
+			fc.owner[slot] = 0
+		}
+	}
+	fc.mu.Unlock()
+	deadline := time.Now().Add(time.Second)
+	for len(cc.Nodes()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("nodes=%v", cc.Nodes())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cc.NodeByKey("foo") != fc.servers[fc.owner[Slot("foo")]].Addr() {
+		t.Errorf("foo on %s", cc.NodeByKey("foo"))
+	}
+}
+
+func TestClusterShards(t *testing.T) {
+	fc := newFakeCluster(t, 3)
+	fc.shards = true
+	cc, e := NewClusterClient([]string{"127.0.0.1:1", fc.servers[2].Addr()})
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer cc.Close()
+	if len(cc.Nodes()) != 3 {
+		t.Fatalf("nodes=%v", cc.Nodes())
+	}
+	for slot := 0; slot < ClusterSlots; slot += 1000 {
+		if addr := cc.master(slot); addr != fc.servers[fc.owner[slot]].Addr() {
+			t.Errorf("slot %d on %s", slot, addr)
+		}
+	}
+}
+
+func TestClusterErrors(t *testing.T) {
+	fc := newFakeCluster(t, 2)
+	cc, e := NewClusterClient([]string{fc.servers[0].Addr()}, WithMaxRedirects(2), WithClusterRefresh(0))
+	if e != nil {
+		t.Fatal(e)
+	}
+	fc.mu.Lock()
+	fc.loop = true
+	fc.mu.Unlock()
+	if _, e := cc.Call("GET", "foo"); e != ErrTooManyRedirects {
+		t.Errorf("e=%v", e)
+	}
+	if n := fc.servers[0].Count("GET") + fc.servers[1].Count("GET"); n != 3 {
+		t.Errorf("GET %d times", n)
+	}
+
+	// the wait of TRYAGAIN is canceled by ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, e := cc.CallContext(ctx, "RENAME", "{a}1", "{a}2"); e != context.DeadlineExceeded || time.Since(start) > RetryWaitSeconds/20 {
+		t.Errorf("e=%v after %v", e, time.Since(start))
+	}
+
+	cc.Close()
+	if _, e := cc.Call("GET", "foo"); e != ErrPoolClosed {
+		t.Errorf("e=%v after closed", e)
+	}
+
+	s := newFakeServer(t, nil)
+	s.Close()
+	if _, e := NewClusterClient([]string{s.Addr()}); e == nil {
+		t.Error("no error of unreachable seed")
+	}
+	if _, e := NewClusterClient(nil); e != ErrNoServer {
+		t.Errorf("e=%v", e)
+	}
+	if _, e := NewClusterClient([]string{"redis://:secret@10.0.0.1/db"}); e == nil || strings.Contains(e.Error(), "secret") {
+		t.Errorf("e=%v", e)
+	}
+}
@@ -11,7 +11,7 @@ import (
 func (c *ConnDriver) AUTH(password string) (bool, error) {
 	v, e := c.Call("AUTH", password)
 	if e != nil {
-		Debug("AUTH failed:"+e.Error(), c.Address)
+		c.client.option.getLogger().Error("AUTH failed", "addr", c.master().Address, "err", e)
 		return false, e
 	}
 
@@ -26,12 +26,13 @@ func (c *ConnDriver) AUTH(password string) (bool, error) {
 	return false, errors.New("invaild response string:" + string(r))
 }
 
+// Deprecated: shut down the driver for every user of it, use Close to get the error
 func (c *ConnDriver) QUIT() {
-
+	c.Close()
 }
 
 func (c *ConnDriver) IsAlive() bool {
-	// v, e := c.CallN(RetryTimes, "PING")
+	// v, e := c.CallN(c.retry, "PING")
 	v, e := c.Call("PING")
 	if e != nil {
 		return false
@@ -59,7 +60,7 @@ func (c *ConnDriver) Info() ([]byte, error) {
 }
 
 func (c *ConnDriver) DEL(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "DEL", key)
+	n, e := c.CallN(c.retry, "DEL", key)
 	if e != nil {
 		return -1, e
 	}
@@ -74,7 +75,7 @@ func (c *ConnDriver) DELMulti(keys []string) (int64, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i] = keys[i]
 	}
-	n, e := c.CallN(RetryTimes, "DEL", args...)
+	n, e := c.CallN(c.retry, "DEL", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -85,7 +86,7 @@ func (c *ConnDriver) DELMulti(keys []string) (int64, error) {
 }
 
 func (c *ConnDriver) DUMP(key string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "DUMP", key)
+	v, e := c.CallN(c.retry, "DUMP", key)
 	if e != nil {
 		return nil, e
 	}
@@ -99,7 +100,7 @@ func (c *ConnDriver) DUMP(key string) ([]byte, error) {
 }
 
 func (c *ConnDriver) EXISTS(key string) (bool, error) {
-	n, e := c.CallN(RetryTimes, "EXISTS", key)
+	n, e := c.CallN(c.retry, "EXISTS", key)
 	if e != nil {
 		return false, e
 	}
@@ -116,7 +117,7 @@ func (c *ConnDriver) EXISTS(key string) (bool, error) {
 }
 
 func (c *ConnDriver) EXPIRE(key string, seconds int64) (bool, error) {
-	n, e := c.CallN(RetryTimes, "EXPIRE", key, seconds)
+	n, e := c.CallN(c.retry, "EXPIRE", key, seconds)
 	if e != nil {
 		return false, e
 	}
@@ -133,7 +134,7 @@ func (c *ConnDriver) EXPIRE(key string, seconds int64) (bool, error) {
 }
 
 func (c *ConnDriver) EXPIREAT(key string, timestamp int64) (bool, error) {
-	n, e := c.CallN(RetryTimes, "EXPIREAT", key, timestamp)
+	n, e := c.CallN(c.retry, "EXPIREAT", key, timestamp)
 	if e != nil {
 		return false, e
 	}
@@ -150,7 +151,7 @@ func (c *ConnDriver) EXPIREAT(key string, timestamp int64) (bool, error) {
 }
 
 func (c *ConnDriver) KEYS(pattern string) ([][]byte, error) {
-	v, e := c.CallN(RetryTimes, "KEYS", pattern)
+	v, e := c.CallN(c.retry, "KEYS", pattern)
 	if e != nil {
 		return nil, e
 	}
@@ -167,7 +168,7 @@ func (c *ConnDriver) KEYS(pattern string) ([][]byte, error) {
 
 // since 2.6.0  COPY and REPLACE will be available in 3.0
 func (c *ConnDriver) MIGRATE(host, port, key, destDB string, timeout int, COPY, REPLACE bool) (bool, error) {
-	v, e := c.CallN(RetryTimes, "MIGRATE", host, port, key, destDB, timeout)
+	v, e := c.CallN(c.retry, "MIGRATE", host, port, key, destDB, timeout)
 	if e != nil {
 		return false, e
 	}
@@ -187,20 +188,13 @@ func (c *ConnDriver) MIGRATE(host, port, key, destDB string, timeout int, COPY,
 	return false, errors.New("migrate false")
 }
 
+// the db of pooled conns is reset when pushed back, set it by url or WithDB
 func (c *ConnDriver) SELECT(index int) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "SELECT", index)
-	if e != nil {
-		return nil, e
-	}
-	if _, ok := v.([]byte); !ok {
-		return nil, ErrResponseType
-	}
-
-	return v.([]byte), nil
+	return nil, ErrPooledSelect
 }
 
 func (c *ConnDriver) MOVE(key, db string) (bool, error) {
-	n, e := c.CallN(RetryTimes, "MOVE", key, db)
+	n, e := c.CallN(c.retry, "MOVE", key, db)
 	if e != nil {
 		return false, e
 	}
@@ -216,7 +210,7 @@ func (c *ConnDriver) MOVE(key, db string) (bool, error) {
 }
 
 func (c *ConnDriver) OBJECT(subcommand, key string) (interface{}, error) {
-	v, e := c.CallN(RetryTimes, "OBJECT", subcommand, key)
+	v, e := c.CallN(c.retry, "OBJECT", subcommand, key)
 	if e != nil {
 		return nil, e
 	}
@@ -237,7 +231,7 @@ func (c *ConnDriver) OBJECT(subcommand, key string) (interface{}, error) {
 }
 
 func (c *ConnDriver) PERSIST(key string) (bool, error) {
-	n, e := c.CallN(RetryTimes, "PERSIST", key)
+	n, e := c.CallN(c.retry, "PERSIST", key)
 	if e != nil {
 		return false, e
 	}
@@ -254,7 +248,7 @@ func (c *ConnDriver) PERSIST(key string) (bool, error) {
 }
 
 func (c *ConnDriver) PEXPIRE(key string, milliseconds int64) (bool, error) {
-	n, e := c.CallN(RetryTimes, "EXPIRE", key, milliseconds)
+	n, e := c.CallN(c.retry, "EXPIRE", key, milliseconds)
 	if e != nil {
 		return false, e
 	}
@@ -271,7 +265,7 @@ func (c *ConnDriver) PEXPIRE(key string, milliseconds int64) (bool, error) {
 }
 
 func (c *ConnDriver) PEXPIREAT(key string, milliTimestamp int64) (bool, error) {
-	n, e := c.CallN(RetryTimes, "EXPIREAT", key, milliTimestamp)
+	n, e := c.CallN(c.retry, "EXPIREAT", key, milliTimestamp)
 	if e != nil {
 		return false, e
 	}
@@ -288,7 +282,7 @@ func (c *ConnDriver) PEXPIREAT(key string, milliTimestamp int64) (bool, error) {
 }
 
 func (c *ConnDriver) PTTL(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "PTTL", key)
+	n, e := c.CallN(c.retry, "PTTL", key)
 	if e != nil {
 		return -1, e
 	}
@@ -301,7 +295,7 @@ func (c *ConnDriver) PTTL(key string) (int64, error) {
 }
 
 func (c *ConnDriver) RANDOMKEY() ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "RANDOMKEY")
+	v, e := c.CallN(c.retry, "RANDOMKEY")
 	if e != nil {
 		return nil, e
 	}
@@ -317,7 +311,7 @@ func (c *ConnDriver) RANDOMKEY() ([]byte, error) {
 }
 
 func (c *ConnDriver) RENAME(key, newkey string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "RENAME", key, newkey)
+	v, e := c.CallN(c.retry, "RENAME", key, newkey)
 	if e != nil {
 		return nil, e
 	}
@@ -330,7 +324,7 @@ func (c *ConnDriver) RENAME(key, newkey string) ([]byte, error) {
 }
 
 func (c *ConnDriver) RENAMENX(key, newkey string) (bool, error) {
-	n, e := c.CallN(RetryTimes, "RENAMENX", key, newkey)
+	n, e := c.CallN(c.retry, "RENAMENX", key, newkey)
 	if e != nil {
 		return false, e
 	}
@@ -348,7 +342,7 @@ func (c *ConnDriver) RENAMENX(key, newkey string) (bool, error) {
 
 // with dump
 func (c *ConnDriver) RESTORE(key string, ttl int, serializedValue string) (bool, error) {
-	v, e := c.CallN(RetryTimes, "RESTORE", key, ttl, serializedValue)
+	v, e := c.CallN(c.retry, "RESTORE", key, ttl, serializedValue)
 	if e != nil {
 		return false, e
 	}
@@ -367,7 +361,7 @@ func (c *ConnDriver) RESTORE(key string, ttl int, serializedValue string) (bool,
 func (c *ConnDriver) SORT() {}
 
 func (c *ConnDriver) TTL(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "TTL", key)
+	n, e := c.CallN(c.retry, "TTL", key)
 	if e != nil {
 		return -1, e
 	}
@@ -375,7 +369,7 @@ func (c *ConnDriver) TTL(key string) (int64, error) {
 }
 
 func (c *ConnDriver) TYPE(key string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "TYPE", key)
+	v, e := c.CallN(c.retry, "TYPE", key)
 	if e != nil {
 		return nil, e
 	}
@@ -396,7 +390,7 @@ func (c *ConnDriver) SCAN(cursor int, match bool, pattern string, isCount bool,
 	if isCount {
 		args = append(args, "COUNT", count)
 	}
-	v, e := c.CallN(RetryTimes, "SCAN", args...)
+	v, e := c.CallN(c.retry, "SCAN", args...)
 	if e != nil {
 		return 0, nil, e
 	}
@@ -417,7 +411,7 @@ func (c *ConnDriver) SADD(key string, values []string) (int64, error) {
 	for i := 0; i < len(values); i++ {
 		args[i+1] = values[i]
 	}
-	n, e := c.CallN(RetryTimes, "SADD", args...)
+	n, e := c.CallN(c.retry, "SADD", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -435,7 +429,7 @@ func (c *ConnDriver) SREM(key string, values []string) (int64, error) {
 	for i := 0; i < len(values); i++ {
 		args[i+1] = values[i]
 	}
-	n, e := c.CallN(RetryTimes, "SREM", args...)
+	n, e := c.CallN(c.retry, "SREM", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -448,7 +442,7 @@ func (c *ConnDriver) SREM(key string, values []string) (int64, error) {
 }
 
 func (c *ConnDriver) SISMEMBER(key, value string) (int64, error) {
-	v, e := c.CallN(RetryTimes, "SISMEMBER", key, value)
+	v, e := c.CallN(c.retry, "SISMEMBER", key, value)
 	if e != nil {
 		return 0, e
 	}
@@ -461,7 +455,7 @@ func (c *ConnDriver) SISMEMBER(key, value string) (int64, error) {
 }
 
 func (c *ConnDriver) SMEMBERS(key string) ([][]byte, error) {
-	v, e := c.CallN(RetryTimes, "SMEMBERS", key)
+	v, e := c.CallN(c.retry, "SMEMBERS", key)
 	if e != nil {
 		return nil, e
 	}
@@ -479,7 +473,7 @@ func (c *ConnDriver) SMEMBERS(key string) ([][]byte, error) {
 
 // 0说明key不存在
 func (c *ConnDriver) SCARD(key string) (int64, error) {
-	v, e := c.CallN(RetryTimes, "SCARD", key)
+	v, e := c.CallN(c.retry, "SCARD", key)
 	if e != nil {
 		return 0, e
 	}
@@ -496,7 +490,7 @@ func (c *ConnDriver) SINTER(keys []string) ([][]byte, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i] = keys[i]
 	}
-	v, e := c.CallN(RetryTimes, "SINTER", args...)
+	v, e := c.CallN(c.retry, "SINTER", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -518,7 +512,7 @@ func (c *ConnDriver) SINTERSTORE(key string, keys []string) (int64, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i+1] = keys[i]
 	}
-	n, e := c.CallN(RetryTimes, "SINTERSTORE", args...)
+	n, e := c.CallN(c.retry, "SINTERSTORE", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -535,7 +529,7 @@ func (c *ConnDriver) SDIFF(keys []string) ([][]byte, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i] = keys[i]
 	}
-	v, e := c.CallN(RetryTimes, "SDIFF", args...)
+	v, e := c.CallN(c.retry, "SDIFF", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -557,7 +551,7 @@ func (c *ConnDriver) SDIFFSTORE(key string, keys []string) (int64, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i+1] = keys[i]
 	}
-	n, e := c.CallN(RetryTimes, "SDIFFSTORE", args...)
+	n, e := c.CallN(c.retry, "SDIFFSTORE", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -571,7 +565,7 @@ func (c *ConnDriver) SDIFFSTORE(key string, keys []string) (int64, error) {
 
 // TODO:return bool
 func (c *ConnDriver) SMOVE(srcKey, desKey, member string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "SMOVE", srcKey, desKey, member)
+	n, e := c.CallN(c.retry, "SMOVE", srcKey, desKey, member)
 	if e != nil {
 		return -1, e
 	}
@@ -584,7 +578,7 @@ func (c *ConnDriver) SMOVE(srcKey, desKey, member string) (int64, error) {
 }
 
 func (c *ConnDriver) SPOP(key string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "SPOP", key)
+	v, e := c.CallN(c.retry, "SPOP", key)
 	if e != nil {
 		return nil, e
 	}
@@ -601,7 +595,7 @@ func (c *ConnDriver) SPOP(key string) ([]byte, error) {
 
 func (c *ConnDriver) SRANDMEMBER(key string, count int) ([][]byte, error) {
 	if count == 0 {
-		v, e := c.CallN(RetryTimes, "SRANDMEMBER", key)
+		v, e := c.CallN(c.retry, "SRANDMEMBER", key)
 		if e != nil {
 			return nil, e
 		}
@@ -609,7 +603,7 @@ func (c *ConnDriver) SRANDMEMBER(key string, count int) ([][]byte, error) {
 		members[0] = v.([]byte)
 		return members, nil
 	}
-	v, e := c.CallN(RetryTimes, "SRANDMEMBER", key, count)
+	v, e := c.CallN(c.retry, "SRANDMEMBER", key, count)
 	if e != nil {
 		return nil, e
 	}
@@ -634,7 +628,7 @@ func (c *ConnDriver) SUNION(keys []string) ([][]byte, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i] = keys[i]
 	}
-	v, e := c.CallN(RetryTimes, "SUNION", args...)
+	v, e := c.CallN(c.retry, "SUNION", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -655,7 +649,7 @@ func (c *ConnDriver) SUNIONSTORE(key string, keys []string) (int64, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i+1] = keys[i]
 	}
-	n, e := c.CallN(RetryTimes, "SUNIONSTORE", args...)
+	n, e := c.CallN(c.retry, "SUNIONSTORE", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -669,7 +663,7 @@ func (c *ConnDriver) SUNIONSTORE(key string, keys []string) (int64, error) {
 
 /******************* strings commands *******************/
 func (c *ConnDriver) APPEND(key, value string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "APPEND", key, value)
+	n, e := c.CallN(c.retry, "APPEND", key, value)
 	if e != nil {
 		return -1, e
 	}
@@ -682,7 +676,7 @@ func (c *ConnDriver) APPEND(key, value string) (int64, error) {
 }
 
 func (c *ConnDriver) BITCOUNT(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "BITCOUNT", key)
+	n, e := c.CallN(c.retry, "BITCOUNT", key)
 	if e != nil {
 		return -1, e
 	}
@@ -702,7 +696,7 @@ func (c *ConnDriver) BITOP(op, dest string, keys []string) (int64, error) {
 	for i := 0; i < len(keys); i++ {
 		args[i+2] = keys[i]
 	}
-	n, e := c.CallN(RetryTimes, "BITOP", args...)
+	n, e := c.CallN(c.retry, "BITOP", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -718,7 +712,7 @@ func (c *ConnDriver) BITOP(op, dest string, keys []string) (int64, error) {
 func (c *ConnDriver) BITPOS() {}
 
 func (c *ConnDriver) DECR(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "DECR", key)
+	n, e := c.CallN(c.retry, "DECR", key)
 	if e != nil {
 		return -1, e
 	}
@@ -731,7 +725,7 @@ func (c *ConnDriver) DECR(key string) (int64, error) {
 }
 
 func (c *ConnDriver) DECRBY(key string, num int) (int64, error) {
-	n, e := c.CallN(RetryTimes, "DECRBY", key, num)
+	n, e := c.CallN(c.retry, "DECRBY", key, num)
 	if e != nil {
 		return -1, e
 	}
@@ -744,7 +738,7 @@ func (c *ConnDriver) DECRBY(key string, num int) (int64, error) {
 }
 
 func (c *ConnDriver) INCR(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "INCR", key)
+	n, e := c.CallN(c.retry, "INCR", key)
 	if e != nil {
 		return -1, e
 	}
@@ -757,7 +751,7 @@ func (c *ConnDriver) INCR(key string) (int64, error) {
 }
 
 func (c *ConnDriver) INCRBY(key string, num int) (int64, error) {
-	n, e := c.CallN(RetryTimes, "INCRBY", key, num)
+	n, e := c.CallN(c.retry, "INCRBY", key, num)
 	if e != nil {
 		return -1, e
 	}
@@ -770,7 +764,7 @@ func (c *ConnDriver) INCRBY(key string, num int) (int64, error) {
 }
 
 func (c *ConnDriver) INCRBYFLOAT(key string, f float64) ([]byte, error) {
-	n, e := c.CallN(RetryTimes, "INCRBYFLOAT", key, f)
+	n, e := c.CallN(c.retry, "INCRBYFLOAT", key, f)
 	if e != nil {
 		return nil, e
 	}
@@ -783,7 +777,7 @@ func (c *ConnDriver) INCRBYFLOAT(key string, f float64) ([]byte, error) {
 }
 
 func (c *ConnDriver) SET(key, value string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "SET", key, value)
+	v, e := c.CallN(c.retry, "SET", key, value)
 	if e != nil {
 		return nil, e
 	}
@@ -797,7 +791,7 @@ func (c *ConnDriver) SET(key, value string) ([]byte, error) {
 
 // 应该返回interface还是[]byte?
 func (c *ConnDriver) GET(key string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "GET", key)
+	v, e := c.CallN(c.retry, "GET", key)
 	if e != nil {
 		return nil, e
 	}
@@ -813,7 +807,7 @@ func (c *ConnDriver) GET(key string) ([]byte, error) {
 }
 
 func (c *ConnDriver) GETBIT(key string, pos int) (int64, error) {
-	n, e := c.CallN(RetryTimes, "GETBIT", key, pos)
+	n, e := c.CallN(c.retry, "GETBIT", key, pos)
 	if e != nil {
 		return -1, e
 	}
@@ -826,7 +820,7 @@ func (c *ConnDriver) GETBIT(key string, pos int) (int64, error) {
 }
 
 func (c *ConnDriver) GETRANGE(key string, start, end int) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "GETRANGE", key, start, end)
+	v, e := c.CallN(c.retry, "GETRANGE", key, start, end)
 	if e != nil {
 		return nil, e
 	}
@@ -839,7 +833,7 @@ func (c *ConnDriver) GETRANGE(key string, start, end int) ([]byte, error) {
 }
 
 func (c *ConnDriver) GETSET(key, value string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "GETSET", key, value)
+	v, e := c.CallN(c.retry, "GETSET", key, value)
 	if e != nil {
 		return nil, e
 	}
@@ -860,7 +854,7 @@ func (c *ConnDriver) MGET(keys []string) ([]interface{}, error) {
 	for k, v := range keys {
 		args[k] = v
 	}
-	v, e := c.CallN(RetryTimes, "MGET", args...)
+	v, e := c.CallN(c.retry, "MGET", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -880,7 +874,7 @@ func (c *ConnDriver) MSET(kv map[string]string) ([]byte, error) {
 		args[i+1] = v
 		i = i + 2
 	}
-	v, e := c.CallN(RetryTimes, "MSET", args...)
+	v, e := c.CallN(c.retry, "MSET", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -900,7 +894,7 @@ func (c *ConnDriver) MSETNX(kv map[string]string) (int64, error) {
 		args[i+1] = v
 		i = i + 2
 	}
-	v, e := c.CallN(RetryTimes, "MSETNX", args...)
+	v, e := c.CallN(c.retry, "MSETNX", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -913,7 +907,7 @@ func (c *ConnDriver) MSETNX(kv map[string]string) (int64, error) {
 }
 
 func (c *ConnDriver) PSETEX(key string, millonseconds int64, value string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "PSETEX", key, millonseconds, value)
+	v, e := c.CallN(c.retry, "PSETEX", key, millonseconds, value)
 	if e != nil {
 		return nil, e
 	}
@@ -926,7 +920,7 @@ func (c *ConnDriver) PSETEX(key string, millonseconds int64, value string) ([]by
 }
 
 func (c *ConnDriver) SETBIT(key string, pos, value int) (int64, error) {
-	n, e := c.CallN(RetryTimes, "SETBIT", key, pos, value)
+	n, e := c.CallN(c.retry, "SETBIT", key, pos, value)
 	if e != nil {
 		return -1, e
 	}
@@ -939,7 +933,7 @@ func (c *ConnDriver) SETBIT(key string, pos, value int) (int64, error) {
 }
 
 func (c *ConnDriver) SETEX(key string, seconds int64, value string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "SETEX", key, seconds, value)
+	v, e := c.CallN(c.retry, "SETEX", key, seconds, value)
 	if e != nil {
 		return nil, e
 	}
@@ -952,7 +946,7 @@ func (c *ConnDriver) SETEX(key string, seconds int64, value string) ([]byte, err
 }
 
 func (c *ConnDriver) SETNX(key, value string) (int64, error) {
-	v, e := c.CallN(RetryTimes, "SETNX", key, value)
+	v, e := c.CallN(c.retry, "SETNX", key, value)
 	if e != nil {
 		return -1, e
 	}
@@ -965,7 +959,7 @@ func (c *ConnDriver) SETNX(key, value string) (int64, error) {
 }
 
 func (c *ConnDriver) SETRANGE(key string, offset int, value string) (int64, error) {
-	v, e := c.CallN(RetryTimes, "SETRANGE", key, offset, value)
+	v, e := c.CallN(c.retry, "SETRANGE", key, offset, value)
 	if e != nil {
 		return -1, e
 	}
@@ -978,7 +972,7 @@ func (c *ConnDriver) SETRANGE(key string, offset int, value string) (int64, erro
 }
 
 func (c *ConnDriver) STRLEN(key string) (int64, error) {
-	v, e := c.CallN(RetryTimes, "STRLEN", key)
+	v, e := c.CallN(c.retry, "STRLEN", key)
 	if e != nil {
 		return -1, e
 	}
@@ -999,7 +993,7 @@ func (c *ConnDriver) SSCAN(key string, cursor int, match bool, pattern string, i
 	if isCount {
 		args = append(args, "COUNT", count)
 	}
-	v, e := c.CallN(RetryTimes, "SSCAN", args...)
+	v, e := c.CallN(c.retry, "SSCAN", args...)
 	if e != nil {
 		return 0, nil, e
 	}
@@ -1020,7 +1014,7 @@ func (c *ConnDriver) HDEL(key string, fields []string) (int64, error) {
 	for i := 0; i < len(fields); i++ {
 		args[i+1] = fields[i]
 	}
-	n, e := c.CallN(RetryTimes, "HDEL", args...)
+	n, e := c.CallN(c.retry, "HDEL", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1033,7 +1027,7 @@ func (c *ConnDriver) HDEL(key string, fields []string) (int64, error) {
 }
 
 func (c *ConnDriver) HEXISTS(key string, field string) (bool, error) {
-	n, e := c.CallN(RetryTimes, "HEXISTS", key, field)
+	n, e := c.CallN(c.retry, "HEXISTS", key, field)
 	if e != nil {
 		return false, e
 	}
@@ -1050,7 +1044,7 @@ func (c *ConnDriver) HEXISTS(key string, field string) (bool, error) {
 }
 
 func (c *ConnDriver) HGET(key string, field string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "HGET", key, field)
+	v, e := c.CallN(c.retry, "HGET", key, field)
 	if e != nil {
 		return nil, e
 	}
@@ -1066,7 +1060,7 @@ func (c *ConnDriver) HGET(key string, field string) ([]byte, error) {
 }
 
 func (c *ConnDriver) HGETALL(key string) ([]interface{}, error) {
-	v, e := c.CallN(RetryTimes, "HGETALL", key)
+	v, e := c.CallN(c.retry, "HGETALL", key)
 	if e != nil {
 		return nil, e
 	}
@@ -1103,7 +1097,7 @@ func (c *ConnDriver) HGETALLMAP(key string) (map[string]string, error) {
 }
 
 func (c *ConnDriver) HINCRBY(key string, field string, increment int) (int64, error) {
-	n, e := c.CallN(RetryTimes, "HINCRBY", key, field, increment)
+	n, e := c.CallN(c.retry, "HINCRBY", key, field, increment)
 	if e != nil {
 		return -1, e
 	}
@@ -1116,7 +1110,7 @@ func (c *ConnDriver) HINCRBY(key string, field string, increment int) (int64, er
 }
 
 func (c *ConnDriver) HINCRBYFLOAT(key string, field string, increment float64) ([]byte, error) {
-	n, e := c.CallN(RetryTimes, "HINCRBYFLOAT", key, field, increment)
+	n, e := c.CallN(c.retry, "HINCRBYFLOAT", key, field, increment)
 	if e != nil {
 		return nil, e
 	}
@@ -1129,7 +1123,7 @@ func (c *ConnDriver) HINCRBYFLOAT(key string, field string, increment float64) (
 }
 
 func (c *ConnDriver) HKEYS(key string) ([][]byte, error) {
-	v, e := c.CallN(RetryTimes, "HKEYS", key)
+	v, e := c.CallN(c.retry, "HKEYS", key)
 	if e != nil {
 		return nil, e
 	}
@@ -1149,7 +1143,7 @@ func (c *ConnDriver) HKEYS(key string) ([][]byte, error) {
 }
 
 func (c *ConnDriver) HLEN(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "HLEN", key)
+	n, e := c.CallN(c.retry, "HLEN", key)
 	if e != nil {
 		return -1, e
 	}
@@ -1167,7 +1161,7 @@ func (c *ConnDriver) HMGET(key string, fields []string) ([]interface{}, error) {
 	for i := 0; i < len(fields); i++ {
 		args[i+1] = fields[i]
 	}
-	v, e := c.CallN(RetryTimes, "HMGET", args...)
+	v, e := c.CallN(c.retry, "HMGET", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -1192,7 +1186,7 @@ func (c *ConnDriver) HMSET(key string, kv map[string]interface{}) ([]byte, error
 		args[i+1] = v
 		i = i + 2
 	}
-	v, e := c.CallN(RetryTimes, "HMSET", args...)
+	v, e := c.CallN(c.retry, "HMSET", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -1205,7 +1199,7 @@ func (c *ConnDriver) HMSET(key string, kv map[string]interface{}) ([]byte, error
 }
 
 func (c *ConnDriver) HSET(key, field string, value interface{}) (int64, error) {
-	n, e := c.CallN(RetryTimes, "HSET", key, field, value)
+	n, e := c.CallN(c.retry, "HSET", key, field, value)
 	if e != nil {
 		return -1, e
 	}
@@ -1218,7 +1212,7 @@ func (c *ConnDriver) HSET(key, field string, value interface{}) (int64, error) {
 }
 
 func (c *ConnDriver) HSETNX(key, field string, value interface{}) (int64, error) {
-	n, e := c.CallN(RetryTimes, "HSETNX", key, field, value)
+	n, e := c.CallN(c.retry, "HSETNX", key, field, value)
 	if e != nil {
 		return -1, e
 	}
@@ -1231,7 +1225,7 @@ func (c *ConnDriver) HSETNX(key, field string, value interface{}) (int64, error)
 }
 
 func (c *ConnDriver) HVALS(key string) ([]interface{}, error) {
-	v, e := c.CallN(RetryTimes, "HVALS", key)
+	v, e := c.CallN(c.retry, "HVALS", key)
 	if e != nil {
 		return nil, e
 	}
@@ -1252,7 +1246,7 @@ func (c *ConnDriver) HSCAN(key string, cursor int, match bool, pattern string, i
 	if isCount {
 		args = append(args, "COUNT", count)
 	}
-	v, e := c.CallN(RetryTimes, "HSCAN", args...)
+	v, e := c.CallN(c.retry, "HSCAN", args...)
 	if e != nil {
 		return 0, nil, e
 	}
@@ -1274,7 +1268,7 @@ func (c *ConnDriver) BLPOP(keys []string, timeout int) ([]interface{}, error) {
 	}
 	args[len(keys)] = timeout
 
-	v, e := c.CallN(RetryTimes, "BLPOP", args...)
+	v, e := c.CallN(c.retry, "BLPOP", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -1296,7 +1290,7 @@ func (c *ConnDriver) BRPOP(keys []string, timeout int) ([]interface{}, error) {
 	}
 	args[len(keys)] = timeout
 
-	v, e := c.CallN(RetryTimes, "BRPOP", args...)
+	v, e := c.CallN(c.retry, "BRPOP", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -1312,7 +1306,7 @@ func (c *ConnDriver) BRPOP(keys []string, timeout int) ([]interface{}, error) {
 }
 
 func (c *ConnDriver) BRPOPLPUSH(source, dest string, timeout int) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "BRPOPLPUSH", source, dest, timeout)
+	v, e := c.CallN(c.retry, "BRPOPLPUSH", source, dest, timeout)
 	if e != nil {
 		return nil, e
 	}
@@ -1328,7 +1322,7 @@ func (c *ConnDriver) BRPOPLPUSH(source, dest string, timeout int) ([]byte, error
 }
 
 func (c *ConnDriver) LINDEX(key string, index int) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "LINDEX", key, index)
+	v, e := c.CallN(c.retry, "LINDEX", key, index)
 	if e != nil {
 		return nil, e
 	}
@@ -1347,7 +1341,7 @@ func (c *ConnDriver) LINSERT(key, dir, pivot, value string) (int64, error) {
 	if strings.ToLower(dir) != "before" && strings.ToLower(dir) != "after" {
 		return -1, errors.New(CommonErrPrefix + "dir only can be (before or after)")
 	}
-	n, e := c.CallN(RetryTimes, "LINSERT", key, dir, pivot, value)
+	n, e := c.CallN(c.retry, "LINSERT", key, dir, pivot, value)
 	if e != nil {
 		return -1, e
 	}
@@ -1360,7 +1354,7 @@ func (c *ConnDriver) LINSERT(key, dir, pivot, value string) (int64, error) {
 }
 
 func (c *ConnDriver) LLEN(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "LLEN", key)
+	n, e := c.CallN(c.retry, "LLEN", key)
 	if e != nil {
 		return -1, e
 	}
@@ -1373,7 +1367,7 @@ func (c *ConnDriver) LLEN(key string) (int64, error) {
 }
 
 func (c *ConnDriver) LPOP(key string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "LPOP", key)
+	v, e := c.CallN(c.retry, "LPOP", key)
 	if e != nil {
 		return nil, e
 	}
@@ -1394,7 +1388,7 @@ func (c *ConnDriver) LPUSH(key string, values []string) (int64, error) {
 	for i, v := range values {
 		args[i+1] = v
 	}
-	n, e := c.CallN(RetryTimes, "LPUSH", args...)
+	n, e := c.CallN(c.retry, "LPUSH", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1407,7 +1401,7 @@ func (c *ConnDriver) LPUSH(key string, values []string) (int64, error) {
 }
 
 func (c *ConnDriver) LPUSHX(key, value string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "LPUSHX", key, value)
+	n, e := c.CallN(c.retry, "LPUSHX", key, value)
 	if e != nil {
 		return -1, e
 	}
@@ -1420,7 +1414,7 @@ func (c *ConnDriver) LPUSHX(key, value string) (int64, error) {
 }
 
 func (c *ConnDriver) LRANGE(key string, start, end int) ([]interface{}, error) {
-	v, e := c.CallN(RetryTimes, "LRANGE", key, start, end)
+	v, e := c.CallN(c.retry, "LRANGE", key, start, end)
 	if e != nil {
 		return nil, e
 	}
@@ -1433,7 +1427,7 @@ func (c *ConnDriver) LRANGE(key string, start, end int) ([]interface{}, error) {
 }
 
 func (c *ConnDriver) LREM(key string, count int, value string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "LREM", key, count, value)
+	n, e := c.CallN(c.retry, "LREM", key, count, value)
 	if e != nil {
 		return -1, e
 	}
@@ -1446,7 +1440,7 @@ func (c *ConnDriver) LREM(key string, count int, value string) (int64, error) {
 }
 
 func (c *ConnDriver) LSET(key string, index int, value string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "LSET", key, index, value)
+	v, e := c.CallN(c.retry, "LSET", key, index, value)
 	if e != nil {
 		return nil, e
 	}
@@ -1459,7 +1453,7 @@ func (c *ConnDriver) LSET(key string, index int, value string) ([]byte, error) {
 }
 
 func (c *ConnDriver) LTRIM(key string, start, end int) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "LTRIM", key, start, end)
+	v, e := c.CallN(c.retry, "LTRIM", key, start, end)
 	if e != nil {
 		return nil, e
 	}
@@ -1472,7 +1466,7 @@ func (c *ConnDriver) LTRIM(key string, start, end int) ([]byte, error) {
 }
 
 func (c *ConnDriver) RPOP(key string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "RPOP", key)
+	v, e := c.CallN(c.retry, "RPOP", key)
 	if e != nil {
 		return nil, e
 	}
@@ -1488,7 +1482,7 @@ func (c *ConnDriver) RPOP(key string) ([]byte, error) {
 }
 
 func (c *ConnDriver) RPOPLPUSH(source, dest string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "RPOPLPUSH", source, dest)
+	v, e := c.CallN(c.retry, "RPOPLPUSH", source, dest)
 	if e != nil {
 		return nil, e
 	}
@@ -1509,7 +1503,7 @@ func (c *ConnDriver) RPUSH(key string, values []string) (int64, error) {
 	for i, v := range values {
 		args[i+1] = v
 	}
-	n, e := c.CallN(RetryTimes, "RPUSH", args...)
+	n, e := c.CallN(c.retry, "RPUSH", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1522,7 +1516,7 @@ func (c *ConnDriver) RPUSH(key string, values []string) (int64, error) {
 }
 
 func (c *ConnDriver) RPUSHX(key, value string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "RPUSHX", key, value)
+	n, e := c.CallN(c.retry, "RPUSHX", key, value)
 	if e != nil {
 		return -1, e
 	}
@@ -1535,7 +1529,7 @@ func (c *ConnDriver) RPUSHX(key, value string) (int64, error) {
 }
 
 func (c *ConnDriver) ZADDSpec(key string, score, value string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "ZADD", key, score, value)
+	n, e := c.CallN(c.retry, "ZADD", key, score, value)
 	if e != nil {
 		return -1, e
 	}
@@ -1556,7 +1550,7 @@ func (c *ConnDriver) ZADD(key string, keyScore map[string]interface{}) (int64, e
 		args[i+1] = k
 		i = i + 2
 	}
-	n, e := c.CallN(RetryTimes, "ZADD", args...)
+	n, e := c.CallN(c.retry, "ZADD", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1569,7 +1563,7 @@ func (c *ConnDriver) ZADD(key string, keyScore map[string]interface{}) (int64, e
 }
 
 func (c *ConnDriver) ZCARD(key string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "ZCARD", key)
+	n, e := c.CallN(c.retry, "ZCARD", key)
 	if e != nil {
 		return -1, e
 	}
@@ -1582,7 +1576,7 @@ func (c *ConnDriver) ZCARD(key string) (int64, error) {
 }
 
 func (c *ConnDriver) ZCOUNT(key string, min, max float64) (int64, error) {
-	n, e := c.CallN(RetryTimes, "ZCOUNT", key, min, max)
+	n, e := c.CallN(c.retry, "ZCOUNT", key, min, max)
 	if e != nil {
 		return -1, e
 	}
@@ -1596,7 +1590,7 @@ func (c *ConnDriver) ZCOUNT(key string, min, max float64) (int64, error) {
 
 // increment could be int, float ,string
 func (c *ConnDriver) ZINCRBY(key string, increment interface{}, member string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "ZINCRBY", key, increment, member)
+	v, e := c.CallN(c.retry, "ZINCRBY", key, increment, member)
 	if e != nil {
 		return nil, e
 	}
@@ -1631,7 +1625,7 @@ func (c *ConnDriver) ZINTERSTORE(destination string, numkeys int, keys []string,
 	if aggregate == true {
 		args = append(args, "AGGREGATE", ag)
 	}
-	n, e := c.CallN(RetryTimes, "ZINTERSTORE", args...)
+	n, e := c.CallN(c.retry, "ZINTERSTORE", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1644,23 +1638,31 @@ func (c *ConnDriver) ZINTERSTORE(destination string, numkeys int, keys []string,
 }
 
 // since 2.8.9
-// func (c *ConnDriver) ZLEXCOUNT(key, min, max string) (int64, error) {
-// 	n, e := c.CallN(RetryTimes,"ZLEXCOUNT", key, min, max)
-// 	if e != nil {
-// 		return -1, e
-// 	}
-// 	return n.(int64), nil
-// }
+func (c *ConnDriver) ZLEXCOUNT(key, min, max string) (int64, error) {
+	if e := c.since("2.8.9"); e != nil {
+		return -1, e
+	}
+	n, e := c.CallN(c.retry, "ZLEXCOUNT", key, min, max)
+	if e != nil {
+		return -1, e
+	}
+
+	if _, ok := n.(int64); !ok {
+		return -1, ErrResponseType
+	}
+
+	return n.(int64), nil
+}
 
 func (c *ConnDriver) ZRANGE(key string, start, stop int, withscores bool) ([]interface{}, error) {
 	if withscores == true {
-		v, e := c.CallN(RetryTimes, "ZRANGE", key, start, stop, "WITHSCORES")
+		v, e := c.CallN(c.retry, "ZRANGE", key, start, stop, "WITHSCORES")
 		if e != nil {
 			return nil, e
 		}
 		return v.([]interface{}), nil
 	}
-	v, e := c.CallN(RetryTimes, "ZRANGE", key, start, stop)
+	v, e := c.CallN(c.retry, "ZRANGE", key, start, stop)
 	if e != nil {
 		return nil, e
 	}
@@ -1673,36 +1675,52 @@ func (c *ConnDriver) ZRANGE(key string, start, stop int, withscores bool) ([]int
 }
 
 // since 2.8.9
-// func (c *ConnDriver) ZRANGEBYLEX(key, min, max string, limit bool, offset, count int) ([]interface{}, error) {
-// 	args := make([]interface{}, 3)
-// 	args[0] = key
-// 	args[1] = min
-// 	args[2] = max
-// 	if limit {
-// 		args = append(args, "LIMIT", offset, count)
-// 	}
-// 	v, e := c.CallN(RetryTimes,"ZRANGEBYLEX", args...)
-// 	if e != nil {
-// 		return nil, e
-// 	}
-// 	return v.([]interface{}), nil
-// }
+func (c *ConnDriver) ZRANGEBYLEX(key, min, max string, limit bool, offset, count int) ([]interface{}, error) {
+	if e := c.since("2.8.9"); e != nil {
+		return nil, e
+	}
+	args := make([]interface{}, 3)
+	args[0] = key
+	args[1] = min
+	args[2] = max
+	if limit {
+		args = append(args, "LIMIT", offset, count)
+	}
+	v, e := c.CallN(c.retry, "ZRANGEBYLEX", args...)
+	if e != nil {
+		return nil, e
+	}
+
+	if _, ok := v.([]interface{}); !ok {
+		return nil, ErrResponseType
+	}
+
+	return v.([]interface{}), nil
+}
 
 // 2.8.9
-// func (c *ConnDriver) ZREVRANGEBYLEX(key, max, min string, limit bool, offset, count int) ([]interface{}, error) {
-// 	args := make([]interface{}, 3)
-// 	args[0] = key
-// 	args[1] = max
-// 	args[2] = min
-// 	if limit {
-// 		args = append(args, "LIMIT", offset, count)
-// 	}
-// 	v, e := c.CallN(RetryTimes,"ZREVRANGEBYLEX", args...)
-// 	if e != nil {
-// 		return nil, e
-// 	}
-// 	return v.([]interface{}), nil
-// }
+func (c *ConnDriver) ZREVRANGEBYLEX(key, max, min string, limit bool, offset, count int) ([]interface{}, error) {
+	if e := c.since("2.8.9"); e != nil {
+		return nil, e
+	}
+	args := make([]interface{}, 3)
+	args[0] = key
+	args[1] = max
+	args[2] = min
+	if limit {
+		args = append(args, "LIMIT", offset, count)
+	}
+	v, e := c.CallN(c.retry, "ZREVRANGEBYLEX", args...)
+	if e != nil {
+		return nil, e
+	}
+
+	if _, ok := v.([]interface{}); !ok {
+		return nil, ErrResponseType
+	}
+
+	return v.([]interface{}), nil
+}
 
 func (c *ConnDriver) ZRANGEBYSCORE(key string, min, max interface{}, withScores, limit bool, offset, count interface{}) ([]interface{}, error) {
 	args := make([]interface{}, 3)
@@ -1715,7 +1733,7 @@ func (c *ConnDriver) ZRANGEBYSCORE(key string, min, max interface{}, withScores,
 	if limit {
 		args = append(args, "LIMIT", offset, count)
 	}
-	v, e := c.CallN(RetryTimes, "ZRANGEBYSCORE", args...)
+	v, e := c.CallN(c.retry, "ZRANGEBYSCORE", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -1729,7 +1747,7 @@ func (c *ConnDriver) ZRANGEBYSCORE(key string, min, max interface{}, withScores,
 
 // if key,or member not exists return bulk string nil, else return integer
 func (c *ConnDriver) ZRANK(key, member string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "ZRANK", key, member)
+	n, e := c.CallN(c.retry, "ZRANK", key, member)
 	if e != nil {
 		return -1, e
 	}
@@ -1748,7 +1766,7 @@ func (c *ConnDriver) ZREM(key string, members []string) (int64, error) {
 		args[i] = m
 		i++
 	}
-	n, e := c.CallN(RetryTimes, "ZREM", args...)
+	n, e := c.CallN(c.retry, "ZREM", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1761,16 +1779,24 @@ func (c *ConnDriver) ZREM(key string, members []string) (int64, error) {
 }
 
 // since 2.8.9
-// func (c *ConnDriver) ZREMRANGEBYLEX(key, min, max string) (int64, error) {
-// 	n, e := c.CallN(RetryTimes,"ZREMRANGEBYLEX", key, min, max)
-// 	if e != nil {
-// 		return -1, e
-// 	}
-// 	return n.(int64), nil
-// }
+func (c *ConnDriver) ZREMRANGEBYLEX(key, min, max string) (int64, error) {
+	if e := c.since("2.8.9"); e != nil {
+		return -1, e
+	}
+	n, e := c.CallN(c.retry, "ZREMRANGEBYLEX", key, min, max)
+	if e != nil {
+		return -1, e
+	}
+
+	if _, ok := n.(int64); !ok {
+		return -1, ErrResponseType
+	}
+
+	return n.(int64), nil
+}
 
 func (c *ConnDriver) ZREMRANGEBYRANK(key string, min, max interface{}) (int64, error) {
-	n, e := c.CallN(RetryTimes, "ZREMRANGEBYRANK", key, min, max)
+	n, e := c.CallN(c.retry, "ZREMRANGEBYRANK", key, min, max)
 	if e != nil {
 		return -1, e
 	}
@@ -1783,7 +1809,7 @@ func (c *ConnDriver) ZREMRANGEBYRANK(key string, min, max interface{}) (int64, e
 }
 
 func (c *ConnDriver) ZREMRANGEBYSCORE(key string, min, max interface{}) (int64, error) {
-	n, e := c.CallN(RetryTimes, "ZREMRANGEBYSCORE", key, min, max)
+	n, e := c.CallN(c.retry, "ZREMRANGEBYSCORE", key, min, max)
 	if e != nil {
 		return -1, e
 	}
@@ -1797,13 +1823,13 @@ func (c *ConnDriver) ZREMRANGEBYSCORE(key string, min, max interface{}) (int64,
 
 func (c *ConnDriver) ZREVRANGE(key string, start, stop int, withscores bool) ([]interface{}, error) {
 	if withscores == true {
-		v, e := c.CallN(RetryTimes, "ZREVRANGE", key, start, stop, "WITHSCORES")
+		v, e := c.CallN(c.retry, "ZREVRANGE", key, start, stop, "WITHSCORES")
 		if e != nil {
 			return nil, e
 		}
 		return v.([]interface{}), nil
 	}
-	v, e := c.CallN(RetryTimes, "ZREVRANGE", key, start, stop)
+	v, e := c.CallN(c.retry, "ZREVRANGE", key, start, stop)
 	if e != nil {
 		return nil, e
 	}
@@ -1825,7 +1851,7 @@ func (c *ConnDriver) ZREVRANGEBYSCORE(key string, max, min interface{}, withScor
 	if limit {
 		args = append(args, "LIMIT", offset, count)
 	}
-	v, e := c.CallN(RetryTimes, "ZREVRANGEBYSCORE", args...)
+	v, e := c.CallN(c.retry, "ZREVRANGEBYSCORE", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -1837,7 +1863,7 @@ func (c *ConnDriver) ZREVRANGEBYSCORE(key string, max, min interface{}, withScor
 }
 
 func (c *ConnDriver) ZREVRANK(key, member string) (int64, error) {
-	n, e := c.CallN(RetryTimes, "ZREVRANK", key, member)
+	n, e := c.CallN(c.retry, "ZREVRANK", key, member)
 	if e != nil {
 		return -1, e
 	}
@@ -1849,7 +1875,7 @@ func (c *ConnDriver) ZREVRANK(key, member string) (int64, error) {
 }
 
 func (c *ConnDriver) ZSCORE(key, member string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "ZSCORE", key, member)
+	v, e := c.CallN(c.retry, "ZSCORE", key, member)
 	if e != nil {
 		return nil, e
 	}
@@ -1887,7 +1913,7 @@ func (c *ConnDriver) ZUNIONSTORE(destination string, numkeys int, keys []string,
 	if aggregate == true {
 		args = append(args, "AGGREGATE", ag)
 	}
-	n, e := c.CallN(RetryTimes, "ZUNIONSTORE", args...)
+	n, e := c.CallN(c.retry, "ZUNIONSTORE", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1908,7 +1934,7 @@ func (c *ConnDriver) ZSCAN(key string, cursor int, match bool, pattern string, i
 	if isCount {
 		args = append(args, "COUNT", count)
 	}
-	v, e := c.CallN(RetryTimes, "ZSCAN", args...)
+	v, e := c.CallN(c.retry, "ZSCAN", args...)
 	if e != nil {
 		return 0, nil, e
 	}
@@ -1925,6 +1951,9 @@ func (c *ConnDriver) ZSCAN(key string, cursor int, match bool, pattern string, i
 
 // since 2.8.9
 func (c *ConnDriver) PFADD(key string, elements []string) (int64, error) {
+	if e := c.since("2.8.9"); e != nil {
+		return -1, e
+	}
 	args := make([]interface{}, 1+len(elements))
 	args[0] = key
 	i := 1
@@ -1932,7 +1961,7 @@ func (c *ConnDriver) PFADD(key string, elements []string) (int64, error) {
 		args[i] = element
 		i++
 	}
-	n, e := c.CallN(RetryTimes, "PFADD", args...)
+	n, e := c.CallN(c.retry, "PFADD", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1945,13 +1974,16 @@ func (c *ConnDriver) PFADD(key string, elements []string) (int64, error) {
 }
 
 func (c *ConnDriver) PFCOUNT(keys []string) (int64, error) {
+	if e := c.since("2.8.9"); e != nil {
+		return -1, e
+	}
 	args := make([]interface{}, len(keys))
 	i := 0
 	for _, key := range keys {
 		args[i] = key
 		i++
 	}
-	n, e := c.CallN(RetryTimes, "PFCOUNT", args...)
+	n, e := c.CallN(c.retry, "PFCOUNT", args...)
 	if e != nil {
 		return -1, e
 	}
@@ -1964,6 +1996,9 @@ func (c *ConnDriver) PFCOUNT(keys []string) (int64, error) {
 }
 
 func (c *ConnDriver) PFMERGE(destKey string, sourceKeys []string) ([]byte, error) {
+	if e := c.since("2.8.9"); e != nil {
+		return nil, e
+	}
 	args := make([]interface{}, 1+len(sourceKeys))
 	args[0] = destKey
 	i := 1
@@ -1971,7 +2006,7 @@ func (c *ConnDriver) PFMERGE(destKey string, sourceKeys []string) ([]byte, error
 		args[i] = sourceKey
 		i++
 	}
-	n, e := c.CallN(RetryTimes, "PFMERGE", args...)
+	n, e := c.CallN(c.retry, "PFMERGE", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -1998,7 +2033,7 @@ func (c *ConnDriver) EVAL(script string, numkeys int, keys []string, scriptArgs
 		i++
 	}
 
-	v, e := c.CallN(RetryTimes, "EVAL", args...)
+	v, e := c.CallN(c.retry, "EVAL", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -2019,7 +2054,7 @@ func (c *ConnDriver) EVALSHA(sha1 string, numkeys int, keys []string, scriptArgs
 		i++
 	}
 
-	v, e := c.CallN(RetryTimes, "EVALSHA", args...)
+	v, e := c.CallN(c.retry, "EVALSHA", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -2034,7 +2069,7 @@ func (c *ConnDriver) SCRIPTEXISTS(scripts []string) ([]interface{}, error) {
 		args[i] = script
 		i++
 	}
-	v, e := c.CallN(RetryTimes, "SCRIPT", args...)
+	v, e := c.CallN(c.retry, "SCRIPT", args...)
 	if e != nil {
 		return nil, e
 	}
@@ -2047,7 +2082,7 @@ func (c *ConnDriver) SCRIPTEXISTS(scripts []string) ([]interface{}, error) {
 }
 
 func (c *ConnDriver) SCRIPTFLUSH() ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "SCRIPT", "FLUSH")
+	v, e := c.CallN(c.retry, "SCRIPT", "FLUSH")
 	if e != nil {
 		return nil, e
 	}
@@ -2060,7 +2095,7 @@ func (c *ConnDriver) SCRIPTFLUSH() ([]byte, error) {
 }
 
 func (c *ConnDriver) SCRIPTKILL() ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "SCRIPT", "KILL")
+	v, e := c.CallN(c.retry, "SCRIPT", "KILL")
 	if e != nil {
 		return nil, e
 	}
@@ -2073,7 +2108,7 @@ func (c *ConnDriver) SCRIPTKILL() ([]byte, error) {
 }
 
 func (c *ConnDriver) SCRIPTLOAD(script string) ([]byte, error) {
-	v, e := c.CallN(RetryTimes, "SCRIPT", "LOAD", script)
+	v, e := c.CallN(c.retry, "SCRIPT", "LOAD", script)
 	if e != nil {
 		return nil, e
 	}
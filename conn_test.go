@@ -1,3 +1,9 @@
+//go:build ignore
+// +build ignore
+
+// legacy tests of the commands on *Conn against the server at 10.16.15.121,
+// kept for reference, the commands are on ConnDriver now
+
 package redis
 
 import (
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// server which does not reply BLPOP until the test finished
+func newBlockingServer(t *testing.T) *fakeServer {
+	release := make(chan struct{})
+	s := newFakeServer(t, func(sess *fakeSession, args []string) string {
+		if args[0] == "BLPOP" {
+			<-release
+			return nilReply()
+		}
+		return fakeRedis(sess, args)
+	})
+	t.Cleanup(func() { close(release) })
+	return s
+}
+
+func TestCallContext(t *testing.T) {
+	s := newBlockingServer(t)
+	p := NewPool(s.Addr(), "", 10, 10, 10)
+
+	c := p.Pop()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, e := c.CallContext(ctx, "PING"); e != context.Canceled {
+		t.Errorf("canceled e=%v", e)
+	}
+	if s.Count("PING") != 0 || c.err != nil {
+		t.Error("canceled ctx should not send or break the conn")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, e := c.CallContext(ctx, "BLPOP", "list", 0); e != context.DeadlineExceeded {
+		t.Errorf("deadline e=%v", e)
+	}
+	if cost := time.Since(start); cost > time.Second {
+		t.Errorf("deadline not applied, cost=%v", cost)
+	}
+	if c.err == nil {
+		t.Error("conn with unread reply should be broken")
+	}
+	p.Push(c)
+	if p.Idles() != 0 {
+		t.Error("broken conn should be discarded")
+	}
+
+	c = p.Pop()
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, e := c.CallContext(ctx, "BLPOP", "list", 0); e != context.Canceled {
+		t.Errorf("cancel e=%v", e)
+	}
+	p.Push(c)
+}
+
+func TestPopContext(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p := NewPool(s.Addr(), "", 1, 1, 10)
+	c, e := p.PopContext(context.Background())
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, e := p.PopContext(ctx); e != context.DeadlineExceeded {
+		t.Errorf("e=%v", e)
+	}
+	if cost := time.Since(start); cost > 500*time.Millisecond {
+		t.Errorf("waiting not aborted, cost=%v", cost)
+	}
+	p.Push(c)
+}
+
+func TestDriverWithContext(t *testing.T) {
+	s := newBlockingServer(t)
+	client, e := NewClient([]string{s.Addr()})
+	if e != nil {
+		t.Fatal(e)
+	}
+	cd := client.Driver("")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, e := cd.WithContext(ctx).BLPOP([]string{"list"}, 0); e != context.DeadlineExceeded {
+		t.Errorf("e=%v", e)
+	}
+	if cd.Context() != context.Background() {
+		t.Error("WithContext should not change the driver")
+	}
+	if _, e := cd.WithContext(context.Background()).SET("key", "value"); e != nil {
+		t.Error(e)
+	}
+	if s.Count("BLPOP") != 1 {
+		t.Errorf("BLPOP should not be retried after deadline, count=%d", s.Count("BLPOP"))
+	}
+}
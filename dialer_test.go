@@ -0,0 +1,159 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redis.sock")
+	ln, e := net.Listen("unix", path)
+	if e != nil {
+		t.Skip("unix socket not supported:", e)
+	}
+	s := serveFake(t, ln, nil)
+
+	client, e := NewClient([]string{"unix://" + path + "?db=1"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	cd := client.Driver(path)
+	if cd == nil {
+		t.Fatal("driver of socket path not found")
+	}
+	if _, e := cd.SET("key", "value"); e != nil {
+		t.Fatal(e)
+	}
+	if s.DB(1)["key"] != "value" {
+		t.Error("key should be set in db 1")
+	}
+}
+
+func TestDialer(t *testing.T) {
+	s := newFakeServer(t, nil)
+	var dialed []string
+	dialer := DialFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, errors.New("connect timeout not set to context")
+		}
+		dialed = append(dialed, network+"://"+address)
+		return s.Pipe(), nil
+	})
+
+	client, e := NewClient([]string{"10.255.255.1:6379"}, WithDialer(dialer))
+	if e != nil {
+		t.Fatal(e)
+	}
+	cd := client.Driver("")
+	if _, e := cd.INCR("n"); e != nil {
+		t.Fatal(e)
+	}
+	if len(dialed) != 1 || dialed[0] != "tcp://10.255.255.1:6379" {
+		t.Errorf("dialed=%v", dialed)
+	}
+	if s.DB(0)["n"] != "1" {
+		t.Error("INCR should be served by the pipe")
+	}
+
+	failed := DialFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("proxy refused")
+	})
+	if _, e := DialURL("redis://10.255.255.1:6379", WithDialer(failed)); e == nil || e.Error() != "proxy refused" {
+		t.Errorf("e=%v", e)
+	}
+}
+
+// conn recording the socket options set
+type optionConn struct {
+	net.Conn
+	keepAlive       bool
+	keepAlivePeriod time.Duration
+	noDelay         bool
+}
+
+func (c *optionConn) SetKeepAlive(keepAlive bool) error {
+	c.keepAlive = keepAlive
+	return nil
+}
+
+func (c *optionConn) SetKeepAlivePeriod(d time.Duration) error {
+	c.keepAlivePeriod = d
+	return nil
+}
+
+func (c *optionConn) SetNoDelay(noDelay bool) error {
+	c.noDelay = noDelay
+	return nil
+}
+
+func TestSocketOptions(t *testing.T) {
+	s := newFakeServer(t, nil)
+	var oc *optionConn
+	dialer := DialFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		oc = &optionConn{Conn: s.Pipe(), noDelay: true}
+		return oc, nil
+	})
+
+	p, e := NewPoolURL("redis://"+s.Addr(), WithDialer(dialer), WithKeepAlive(30*time.Second), WithNoDelay(false), WithBufferSize(1024, 2048))
+	if e != nil {
+		t.Fatal(e)
+	}
+	c := p.Pop()
+	if !oc.keepAlive || oc.keepAlivePeriod != 30*time.Second || oc.noDelay {
+		t.Errorf("socket options=%+v", oc)
+	}
+	if c.rb.Size() != 1024 || c.wb.Size() != 2048 {
+		t.Errorf("buffer size read=%d write=%d", c.rb.Size(), c.wb.Size())
+	}
+	p.Push(c)
+
+	c, e = DialURL("redis://"+s.Addr(), WithDialer(dialer), WithKeepAlive(-1))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer c.Close()
+	if oc.keepAlive || !oc.noDelay {
+		t.Errorf("socket options=%+v", oc)
+	}
+	if c.rb.Size() != DefaultReadBufferSize {
+		t.Errorf("buffer size read=%d", c.rb.Size())
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	s := newBlockingServer(t)
+	client, e := NewClient([]string{s.Addr()}, WithTimeout(time.Second, 50*time.Millisecond, time.Second), WithRetryTimes(1))
+	if e != nil {
+		t.Fatal(e)
+	}
+	cd := client.Driver("")
+	if _, e := cd.SET("key", "value"); e != nil {
+		t.Fatal(e)
+	}
+	c := cd.mp.Pop()
+	if c.readTimeout != 50*time.Millisecond || c.writeTimeout != time.Second {
+		t.Errorf("read=%v write=%v", c.readTimeout, c.writeTimeout)
+	}
+	cd.mp.Push(c)
+
+	start := time.Now()
+	_, e = cd.BLPOP([]string{"list"}, 0)
+	if ne, ok := e.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("e=%v", e)
+	}
+	if cost := time.Since(start); cost > 500*time.Millisecond {
+		t.Errorf("read timeout not applied, cost=%v", cost)
+	}
+
+	// override by url
+	p, _ := NewPoolURL("redis://" + s.Addr() + "?read_timeout=100ms")
+	c = p.Pop()
+	if c.readTimeout != 100*time.Millisecond || c.writeTimeout != WriteTimeout {
+		t.Errorf("read=%v write=%v", c.readTimeout, c.writeTimeout)
+	}
+	p.Push(c)
+}
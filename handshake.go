@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrNotSupported = errors.New(CommonErrPrefix + "command not supported by server")
+
+// ServerInfo is the reply of HELLO, or the server section of INFO if HELLO not supported
+type ServerInfo struct {
+	Server  string
+	Version string
+	Proto   int
+	ID      int64
+	Mode    string
+	Role    string
+}
+
+// version of server is not less than version like 2.8.9
+func (s *ServerInfo) AtLeast(version string) bool {
+	have := strings.Split(s.Version, ".")
+	want := strings.Split(version, ".")
+	for i := 0; i < len(want); i++ {
+		w, _ := strconv.Atoi(want[i])
+		h := 0
+		if i < len(have) {
+			h, _ = strconv.Atoi(have[i])
+		}
+		if h != w {
+			return h > w
+		}
+	}
+	return true
+}
+
+// server info got by the handshake, nil if the handshake is not enabled
+func (c *Conn) Server() *ServerInfo {
+	return c.server
+}
+
+// the server support the command since version, true if version of server unknown
+func (c *Conn) Support(version string) bool {
+	return c.server == nil || c.server.AtLeast(version)
+}
+
+// AUTH with the ACL user of redis 6.0
+func (c *Conn) AUTHUser(username, password string) (bool, error) {
+	if username == "" {
+		return c.AUTH(password)
+	}
+	if e := c.expectOK("AUTH", username, password); e != nil {
+		return false, e
+	}
+	return true, nil
+}
+
+// HELLO with protocol 2, AUTH and SETNAME if set, the hooks are not called
+// fallback to AUTH and INFO server if HELLO is not supported by the server
+func (c *Conn) handshake(username, password, clientName string) error {
+	args := []interface{}{2}
+	if password != "" {
+		// AUTH of HELLO requires the username, not the AUTH of fallback before redis 6.0
+		helloUser := username
+		if helloUser == "" {
+			helloUser = "default"
+		}
+		args = append(args, "AUTH", helloUser, password)
+	}
+	if clientName != "" {
+		args = append(args, "SETNAME", clientName)
+	}
+
+	v, e := c.call(context.Background(), "HELLO", args...)
+	if e != nil {
+		if !strings.Contains(e.Error(), "unknown command") {
+			return e
+		}
+		if password != "" {
+			if _, e := c.AUTHUser(username, password); e != nil {
+				return e
+			}
+		}
+		if clientName != "" {
+			if e := c.expectOK("CLIENT", "SETNAME", clientName); e != nil {
+				return e
+			}
+		}
+		v, e = c.call(context.Background(), "INFO", "server")
+		if e != nil {
+			return e
+		}
+		r, ok := v.([]byte)
+		if !ok {
+			return ErrResponseType
+		}
+		c.server = parseInfoServer(r)
+		return nil
+	}
+
+	r, ok := v.([]interface{})
+	if !ok {
+		return ErrResponseType
+	}
+	c.server = parseHello(r)
+	return nil
+}
+
+// HELLO reply is an array of field and value
+func parseHello(r []interface{}) *ServerInfo {
+	info := &ServerInfo{}
+	for i := 0; i+1 < len(r); i += 2 {
+		field, _ := r[i].([]byte)
+		value, _ := r[i+1].([]byte)
+		switch string(field) {
+		case "server":
+			info.Server = string(value)
+		case "version":
+			info.Version = string(value)
+		case "proto":
+			if n, ok := r[i+1].(int64); ok {
+				info.Proto = int(n)
+			}
+		case "id":
+			if n, ok := r[i+1].(int64); ok {
+				info.ID = n
+			}
+		case "mode":
+			info.Mode = string(value)
+		case "role":
+			info.Role = string(value)
+		}
+	}
+	return info
+}
+
+// lines like redis_version:2.8.19 and redis_mode:standalone
+func parseInfoServer(r []byte) *ServerInfo {
+	info := &ServerInfo{Server: "redis", Proto: 2}
+	for _, line := range strings.Split(string(r), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "redis_version":
+			info.Version = kv[1]
+		case "redis_mode":
+			info.Mode = kv[1]
+		}
+	}
+	return info
+}
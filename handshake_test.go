@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"testing"
+)
+
+func TestHelloHandshake(t *testing.T) {
+	s := newFakeServer(t, func(sess *fakeSession, args []string) string {
+		if args[0] == "HELLO" {
+			// HELLO 2 AUTH user pass SETNAME api
+			if len(args) != 7 || args[1] != "2" || args[3] != "user" || args[4] != "pa:ss" || args[6] != "api" {
+				return errReply("WRONGPASS invalid username-password pair")
+			}
+		}
+		return fakeRedis(sess, args)
+	})
+
+	c, e := DialURL("redis://user:pa:ss@"+s.Addr()+"?client_name=api", WithHello())
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer c.Close()
+	server := c.Server()
+	if server == nil || server.Version != "7.2.0" || server.Proto != 2 || server.Role != "master" {
+		t.Fatalf("server=%+v", server)
+	}
+	if !c.Support("2.8.9") || c.Support("7.10") {
+		t.Error("wrong version check")
+	}
+	if s.Count("AUTH") != 0 {
+		t.Error("AUTH should be done by HELLO")
+	}
+
+	if _, e := DialURL("redis://user:wrong@"+s.Addr(), WithHello()); e == nil {
+		t.Error("wrong password should fail")
+	}
+}
+
+func TestHelloFallback(t *testing.T) {
+	s := newFakeServer(t, func(sess *fakeSession, args []string) string {
+		switch args[0] {
+		case "HELLO":
+			return errReply("ERR unknown command 'HELLO'")
+		case "AUTH":
+			if len(args) == 2 && args[1] == "secret" {
+				break
+			}
+			if len(args) != 3 || args[1] != "user" || args[2] != "pass" {
+				return errReply("ERR invalid password")
+			}
+		case "INFO":
+			return bulkReply("# Server\r\nredis_version:2.8.4\r\nredis_mode:standalone\r\n")
+		}
+		return fakeRedis(sess, args)
+	})
+
+	client, e := NewClient([]string{"redis://user:pass@" + s.Addr()}, WithHello())
+	if e != nil {
+		t.Fatal(e)
+	}
+	cd := client.Driver("")
+	if _, e := cd.Call("PING"); e != nil {
+		t.Fatal(e)
+	}
+	server := cd.mp.Server()
+	if server == nil || server.Version != "2.8.4" || server.AtLeast("2.8.9") {
+		t.Fatalf("server=%+v", server)
+	}
+	if _, e := cd.ZLEXCOUNT("zset", "-", "+"); e != ErrNotSupported {
+		t.Errorf("ZLEXCOUNT e=%v", e)
+	}
+	if s.Count("ZLEXCOUNT") != 0 {
+		t.Error("unsupported command should not be sent")
+	}
+
+	// password only is AUTH pass, not AUTH default pass
+	c, e := DialURL("redis://:secret@"+s.Addr(), WithHello())
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer c.Close()
+	if c.Server() == nil || c.Server().Version != "2.8.4" {
+		t.Errorf("server=%+v", c.Server())
+	}
+}
+
+func TestAUTHUser(t *testing.T) {
+	s := newFakeServer(t, nil)
+	c, e := DialURL("redis://user:pass@" + s.Addr())
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer c.Close()
+	if c.Server() != nil || !c.Support("99.0") {
+		t.Error("server should be unknown without handshake")
+	}
+	commands := s.Commands()
+	if len(commands) != 1 || len(commands[0]) != 3 || commands[0][1] != "user" {
+		t.Errorf("commands=%v", commands)
+	}
+}
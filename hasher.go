@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+)
+
+// KeyHasher hash the keys of sharded clients to the ring, only the hash tag of key is passed
+type KeyHasher interface {
+	Hash(key string) uint32
+}
+
+// KeyHasherFunc is a function as KeyHasher
+type KeyHasherFunc func(key string) uint32
+
+func (f KeyHasherFunc) Hash(key string) uint32 {
+	return f(key)
+}
+
+var (
+	// md5 of libketama, the default
+	KetamaHasher KeyHasher = KeyHasherFunc(ketamaKey)
+	// Sum, the hash of the legacy MultiPool
+	SumHasher KeyHasher = KeyHasherFunc(func(key string) uint32 { return uint32(Sum(key)) })
+	// crc16 of redis cluster, in the high 16 bits to spread on the ring
+	CRC16Hasher KeyHasher = KeyHasherFunc(func(key string) uint32 { return uint32(crc16(key)) << 16 })
+	// 32 bits FNV-1a
+	FNVHasher KeyHasher = KeyHasherFunc(fnv32a)
+	// 32 bits murmur3 with seed 0
+	MurmurHasher KeyHasher = KeyHasherFunc(murmur3)
+)
+
+// the part of key hashed: the substring between the first { and the first } after it if not empty,
+// or the whole key, so user:{42}:profile and user:{42}:cart go to the same server
+func HashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+func fnv32a(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+func murmur3(key string) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+	data := []byte(key)
+	var h uint32
+	for len(data) >= 4 {
+		k := binary.LittleEndian.Uint32(data)
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+		data = data[4:]
+	}
+	var k uint32
+	switch len(data) {
+	case 3:
+		k ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(key))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// crc16 XMODEM used by redis cluster for the slot of key
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
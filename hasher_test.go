@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"testing"
+)
+
+func TestHashTag(t *testing.T) {
+	cases := map[string]string{
+		"user:{42}:profile": "42",
+		"user:{42}:cart":    "42",
+		"{user}:{42}":       "user",
+		"user:42":           "user:42",
+		"user:{}:42":        "user:{}:42",
+		"{}{42}":            "{}{42}",
+		"user:{42":          "user:{42",
+		"user:}42{":         "user:}42{",
+		"a{b{c}d}":          "b{c",
+		"":                  "",
+	}
+	for key, tag := range cases {
+		if got := HashTag(key); got != tag {
+			t.Errorf("HashTag(%q)=%q, want %q", key, got, tag)
+		}
+	}
+}
+
+func TestKeyHashers(t *testing.T) {
+	if crc := crc16("123456789"); crc != 0x31c3 {
+		t.Errorf("crc16=%x", crc)
+	}
+	// slot of redis cluster
+	if slot := crc16("foo") % 16384; slot != 12182 {
+		t.Errorf("slot of foo=%d", slot)
+	}
+	if h := FNVHasher.Hash("a"); h != 0xe40c292c {
+		t.Errorf("fnv=%x", h)
+	}
+	murmurs := map[string]uint32{"": 0, "hello": 0x248bfa47, "Hello, world!": 0xc0363e43}
+	for key, want := range murmurs {
+		if h := MurmurHasher.Hash(key); h != want {
+			t.Errorf("murmur3(%q)=%x, want %x", key, h, want)
+		}
+	}
+
+	for name, hasher := range map[string]KeyHasher{
+		"ketama": KetamaHasher,
+		"sum":    SumHasher,
+		"crc16":  CRC16Hasher,
+		"fnv":    FNVHasher,
+		"murmur": MurmurHasher,
+	} {
+		mp, e := NewMultiPoolOptions(shardAddrs(10), WithKeyHasher(hasher))
+		if e != nil {
+			t.Fatal(e)
+		}
+		counts := make(map[string]int)
+		for _, server := range placement(mp) {
+			counts[server]++
+		}
+		if len(counts) != 10 {
+			t.Errorf("%s: keys on %d servers", name, len(counts))
+		}
+		for i := 0; i < 100; i++ {
+			tag := "{" + string(rune('a'+i%26)) + string(rune('a'+i/26)) + "}"
+			if mp.ServerByKey("user:"+tag+":profile") != mp.ServerByKey("user:"+tag+":cart") {
+				t.Errorf("%s: keys of tag %s on different servers", name, tag)
+			}
+		}
+		mp.Close()
+	}
+}
@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// HookEvent passed to the hooks
+type HookEvent struct {
+	Addr     string
+	Conn     *Conn // nil if dial or pop failed, and for the close of pool
+	Command  string
+	Args     []interface{}
+	Result   interface{}
+	Err      error
+	Duration time.Duration // time of dial, pop, call or close, zero in BeforeCall
+}
+
+// Hook run around the lifecycle of conns, pools and calls, nil funcs are skipped
+// hooks added by WithHook run in order, and the AfterCall in reverse order like middlewares
+type Hook struct {
+	// after a conn dialed, the dial fails and the conn is closed if error returned
+	// also called when the dial failed with ev.Err, the error returned is ignored
+	OnDial func(ctx context.Context, ev *HookEvent) error
+	// after Pop returned, ev.Err is set if failed
+	OnPop func(ctx context.Context, ev *HookEvent)
+	// before the conn is pushed back
+	OnPush func(ev *HookEvent)
+	// after the pool is closed, ev.Err is set if not all the conns pushed back
+	OnClose func(ev *HookEvent)
+	// before the command is sent, return true to short-circuit the call with ev.Result and ev.Err,
+	// the hooks after it and the command are skipped,
+	// the internal calls of dial, reset, health check and QUIT are not hooked, the password in ev.Args is xxxxx
+	BeforeCall func(ctx context.Context, ev *HookEvent) bool
+	// after the command is called or short-circuited
+	AfterCall func(ctx context.Context, ev *HookEvent)
+}
+
+// run the call between the BeforeCall and AfterCall of hooks
+func callHooks(ctx context.Context, hooks []Hook, ev *HookEvent, call func() (interface{}, error)) (interface{}, error) {
+	n := len(hooks)
+	short := false
+	for i, h := range hooks {
+		if h.BeforeCall != nil && h.BeforeCall(ctx, ev) {
+			n = i + 1
+			short = true
+			break
+		}
+	}
+	if !short {
+		start := time.Now()
+		ev.Result, ev.Err = call()
+		ev.Duration = time.Since(start)
+	}
+	for i := n - 1; i >= 0; i-- {
+		if h := hooks[i]; h.AfterCall != nil {
+			h.AfterCall(ctx, ev)
+		}
+	}
+	return ev.Result, ev.Err
+}
+
+// args of AUTH and HELLO with the password replaced by xxxxx for the hooks
+func redactArgs(command string, args []interface{}) []interface{} {
+	password := -1
+	switch strings.ToUpper(command) {
+	case "AUTH":
+		password = len(args) - 1
+	case "HELLO":
+		for i := range args {
+			if s, ok := args[i].(string); ok && strings.EqualFold(s, "AUTH") && i+2 < len(args) {
+				password = i + 2
+				break
+			}
+		}
+	}
+	if password < 0 {
+		return args
+	}
+	redacted := make([]interface{}, len(args))
+	copy(redacted, args)
+	redacted[password] = "xxxxx"
+	return redacted
+}
+
+// run OnDial of hooks, stop at the first error
+func dialHooks(ctx context.Context, hooks []Hook, ev *HookEvent) error {
+	for _, h := range hooks {
+		if h.OnDial == nil {
+			continue
+		}
+		if e := h.OnDial(ctx, ev); e != nil && ev.Err == nil {
+			return e
+		}
+	}
+	return nil
+}
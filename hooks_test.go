@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	s := newFakeServer(t, nil)
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+	errVeto := errors.New("vetoed")
+
+	p, _ := NewPoolURL("redis://"+s.Addr(),
+		WithHook(Hook{
+			OnDial: func(ctx context.Context, ev *HookEvent) error {
+				record("dial")
+				if ev.Err != nil || ev.Conn == nil || ev.Addr != s.Addr() {
+					t.Errorf("dial event=%+v", ev)
+				}
+				_, e := ev.Conn.CallContext(ctx, "CLIENT", "SETNAME", "hooked")
+				return e
+			},
+			OnPop: func(ctx context.Context, ev *HookEvent) {
+				record("pop")
+			},
+			OnPush: func(ev *HookEvent) {
+				record("push")
+			},
+			OnClose: func(ev *HookEvent) {
+				record("close")
+			},
+			BeforeCall: func(ctx context.Context, ev *HookEvent) bool {
+				record("before1 " + ev.Command)
+				if ev.Command == "FLUSHALL" {
+					ev.Err = errVeto
+					return true
+				}
+				return false
+			},
+			AfterCall: func(ctx context.Context, ev *HookEvent) {
+				record("after1 " + ev.Command)
+			},
+		}),
+		WithHook(Hook{
+			BeforeCall: func(ctx context.Context, ev *HookEvent) bool {
+				record("before2 " + ev.Command)
+				return false
+			},
+			AfterCall: func(ctx context.Context, ev *HookEvent) {
+				record("after2 " + ev.Command)
+				if ev.Command == "GET" && (string(ev.Result.([]byte)) != "1" || ev.Err != nil || ev.Duration <= 0 || ev.Args[0] != "a") {
+					t.Errorf("call event=%+v", ev)
+				}
+			},
+		}),
+	)
+
+	c := p.Pop()
+	c.Call("SET", "a", "1")
+	c.Call("GET", "a")
+	if _, e := c.Call("FLUSHALL"); e != errVeto {
+		t.Errorf("e=%v", e)
+	}
+	p.Push(c)
+	p.Close()
+
+	want := []string{
+		"dial", "before1 CLIENT", "before2 CLIENT", "after2 CLIENT", "after1 CLIENT", "pop",
+		"before1 SET", "before2 SET", "after2 SET", "after1 SET",
+		"before1 GET", "before2 GET", "after2 GET", "after1 GET",
+		"before1 FLUSHALL", "after1 FLUSHALL",
+		"push", "close",
+	}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events=%v", events)
+	}
+	if s.Count("FLUSHALL") != 0 {
+		t.Error("vetoed command should not be sent")
+	}
+	if cmds := s.Commands(); len(cmds) == 0 || strings.Join(cmds[0], " ") != "CLIENT SETNAME hooked" {
+		t.Errorf("commands=%v", cmds)
+	}
+}
+
+func TestHooksInternalCalls(t *testing.T) {
+	s := newFakeServer(t, nil)
+	var commands []string
+	veto := false
+	p, _ := NewPoolURL("redis://:secret@"+s.Addr()+"/2", WithHook(Hook{
+		BeforeCall: func(ctx context.Context, ev *HookEvent) bool {
+			commands = append(commands, strings.TrimSpace(ev.Command+" "+fmt.Sprint(ev.Args...)))
+			if veto {
+				ev.Err = errors.New("vetoed")
+				return true
+			}
+			return false
+		},
+	}))
+
+	// AUTH and SELECT of dial, UNWATCH of reset, PING and QUIT are not hooked
+	c := p.Pop()
+	c.Call("WATCH", "a")
+	c.Call("AUTH", "secret")
+	veto = true
+	p.Push(c)
+	if p.Idles() != 1 {
+		t.Error("conn should be reset and reused")
+	}
+	if c = p.Pop(); !c.IsAlive() {
+		t.Error("PING vetoed")
+	}
+	p.Push(c)
+	p.Close()
+
+	if strings.Join(commands, ",") != "WATCH a,AUTH xxxxx" {
+		t.Errorf("commands=%v", commands)
+	}
+	for command, n := range map[string]int{"AUTH": 2, "SELECT": 1, "UNWATCH": 1, "PING": 1, "QUIT": 1} {
+		if s.Count(command) != n {
+			t.Errorf("%s %d times", command, s.Count(command))
+		}
+	}
+}
+
+func TestDialHookError(t *testing.T) {
+	s := newFakeServer(t, nil)
+	errDial := errors.New("not allowed")
+	var failed *HookEvent
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithHook(Hook{
+		OnDial: func(ctx context.Context, ev *HookEvent) error {
+			return errDial
+		},
+		OnPop: func(ctx context.Context, ev *HookEvent) {
+			failed = ev
+		},
+	}))
+	if _, e := p.PopContext(context.Background()); e != errDial {
+		t.Fatalf("e=%v", e)
+	}
+	if failed == nil || failed.Err != errDial || failed.Conn != nil {
+		t.Errorf("pop event=%+v", failed)
+	}
+	if info := p.Snapshot(); info.ActiveNum != 0 || info.CreateFailedNum != 1 || info.CloseNum != 0 {
+		t.Errorf("info=%+v", info)
+	}
+	for i := 0; i < 100 && s.Conns() > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if s.Conns() != 0 {
+		t.Error("conn should be closed")
+	}
+}
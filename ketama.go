@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"crypto/md5"
+	"sort"
+	"strconv"
+)
+
+// virtual nodes of each weight on the ring, 4 points each md5
+const ketamaPointsPerWeight = 160
+
+// ketama consistent hash ring, immutable after built
+// the points of a shard only depend on its name and weight,
+// so only the keys of the shard added or removed are moved
+type ketama struct {
+	points []uint32
+	names  []string // name of the shard owning points[i]
+}
+
+type ketamaNode struct {
+	name   string
+	weight int
+}
+
+func newKetama(nodes []ketamaNode) *ketama {
+	k := &ketama{}
+	type point struct {
+		hash uint32
+		name string
+	}
+	var points []point
+	for _, node := range nodes {
+		for i := 0; i < node.weight*ketamaPointsPerWeight/4; i++ {
+			d := md5.Sum([]byte(node.name + "-" + strconv.Itoa(i)))
+			for h := 0; h < 4; h++ {
+				points = append(points, point{ketamaHash(d[h*4:]), node.name})
+			}
+		}
+	}
+	// same hash on two shards goes to the smaller name, not the order of adding
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].name < points[j].name
+	})
+	k.points = make([]uint32, len(points))
+	k.names = make([]string, len(points))
+	for i, p := range points {
+		k.points[i] = p.hash
+		k.names[i] = p.name
+	}
+	return k
+}
+
+// name of the shard of hash, the first point clockwise
+func (k *ketama) get(hash uint32) string {
+	if len(k.points) == 0 {
+		return ""
+	}
+	i := sort.Search(len(k.points), func(i int) bool { return k.points[i] >= hash })
+	if i == len(k.points) {
+		i = 0
+	}
+	return k.names[i]
+}
+
+// hash of key on the ring, same as libketama
+func ketamaKey(key string) uint32 {
+	d := md5.Sum([]byte(key))
+	return ketamaHash(d[:])
+}
+
+func ketamaHash(d []byte) uint32 {
+	return uint32(d[3])<<24 | uint32(d[2])<<16 | uint32(d[1])<<8 | uint32(d[0])
+}
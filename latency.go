@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"math/bits"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// bucket i counts the calls less than 2^i microseconds, the last bucket counts the rest
+const latencyBuckets = 28
+
+// Latency of one command, the percentiles are the upper bounds of the buckets and not more than Max
+// the latency of PING is the round trip of network, the rest of a slow command is spent by the server
+type Latency struct {
+	Count int64
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// histogram of the latency in log2 buckets, updated by atomic
+type histogram struct {
+	counts [latencyBuckets]int64
+	count  int64
+	sum    int64 // nanoseconds
+	max    int64 // nanoseconds
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := bits.Len64(uint64(d.Microseconds()))
+	if i >= latencyBuckets {
+		i = latencyBuckets - 1
+	}
+	atomic.AddInt64(&h.counts[i], 1)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(d))
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&h.max, max, int64(d)) {
+			return
+		}
+	}
+}
+
+func (h *histogram) latency() *Latency {
+	var counts [latencyBuckets]int64
+	var total int64
+	for i := range counts {
+		counts[i] = atomic.LoadInt64(&h.counts[i])
+		total += counts[i]
+	}
+	l := &Latency{Count: total, Max: time.Duration(atomic.LoadInt64(&h.max))}
+	l.P50 = percentile(counts[:], total, 0.50, l.Max)
+	l.P95 = percentile(counts[:], total, 0.95, l.Max)
+	l.P99 = percentile(counts[:], total, 0.99, l.Max)
+	return l
+}
+
+// upper bound of the bucket where the rank q of total falls in
+func percentile(counts []int64, total int64, q float64, max time.Duration) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	rank := int64(q*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var n int64
+	for i, count := range counts {
+		n += count
+		if n >= rank {
+			if bound := bucketBound(i); bound < max {
+				return bound
+			}
+			break
+		}
+	}
+	return max
+}
+
+// record the latency of command
+func (s *PoolStats) observe(command string, d time.Duration) {
+	command = strings.ToUpper(command)
+	s.latencyMu.RLock()
+	h, ok := s.latency[command]
+	s.latencyMu.RUnlock()
+	if !ok {
+		s.latencyMu.Lock()
+		if h, ok = s.latency[command]; !ok {
+			if s.latency == nil {
+				s.latency = make(map[string]*histogram)
+			}
+			h = &histogram{}
+			s.latency[command] = h
+		}
+		s.latencyMu.Unlock()
+	}
+	h.observe(d)
+}
+
+// upper bound of bucket i, the last bucket has no bound
+func bucketBound(i int) time.Duration {
+	return time.Duration(1<<uint(i)) * time.Microsecond
+}
+
+// histogram of each command called
+func (s *PoolStats) histograms() map[string]*histogram {
+	s.latencyMu.RLock()
+	defer s.latencyMu.RUnlock()
+	histograms := make(map[string]*histogram, len(s.latency))
+	for command, h := range s.latency {
+		histograms[command] = h
+	}
+	return histograms
+}
+
+// latency of each command called
+func (s *PoolStats) latencies() map[string]*Latency {
+	s.latencyMu.RLock()
+	defer s.latencyMu.RUnlock()
+	latency := make(map[string]*Latency, len(s.latency))
+	for command, h := range s.latency {
+		latency[command] = h.latency()
+	}
+	return latency
+}
+
+// latency of the command, nil if not called
+func (p *Pool) Latency(command string) *Latency {
+	p.stats.latencyMu.RLock()
+	h, ok := p.stats.latency[strings.ToUpper(command)]
+	p.stats.latencyMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return h.latency()
+}
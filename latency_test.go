@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	if l := h.latency(); l.Count != 0 || l.P99 != 0 || l.Max != 0 {
+		t.Errorf("empty latency=%+v", l)
+	}
+	// 1ms * 90, 10ms * 9, 100ms
+	for i := 0; i < 90; i++ {
+		h.observe(time.Millisecond)
+	}
+	for i := 0; i < 9; i++ {
+		h.observe(10 * time.Millisecond)
+	}
+	h.observe(100 * time.Millisecond)
+
+	l := h.latency()
+	if l.Count != 100 || l.Max != 100*time.Millisecond {
+		t.Fatalf("latency=%+v", l)
+	}
+	// upper bounds of the buckets
+	if l.P50 != 1024*time.Microsecond || l.P95 != 16384*time.Microsecond || l.P99 != 16384*time.Microsecond {
+		t.Errorf("latency=%+v", l)
+	}
+
+	h.observe(time.Hour)
+	if l := h.latency(); l.Max != time.Hour || l.P99 != 131072*time.Microsecond {
+		t.Errorf("latency=%+v", l)
+	}
+}
+
+func TestPoolLatency(t *testing.T) {
+	s := newFakeServer(t, func(sess *fakeSession, args []string) string {
+		if strings.EqualFold(args[0], "HGETALL") {
+			time.Sleep(20 * time.Millisecond)
+			return arrayReply()
+		}
+		return fakeRedis(sess, args)
+	})
+	p, _ := NewPoolURL("redis://" + s.Addr())
+	c := p.Pop()
+	defer p.Push(c)
+
+	for i := 0; i < 3; i++ {
+		c.Call("hgetall", "h")
+		c.Call("PING")
+	}
+	info := p.Info()
+	slow, ping := info.Latency["HGETALL"], info.Latency["PING"]
+	if slow == nil || ping == nil || len(info.Latency) != 2 {
+		t.Fatalf("latency=%v", info.Latency)
+	}
+	if slow.Count != 3 || slow.P50 < 20*time.Millisecond || slow.Max < 20*time.Millisecond {
+		t.Errorf("HGETALL latency=%+v", slow)
+	}
+	if ping.Count != 3 || ping.Max >= 20*time.Millisecond {
+		t.Errorf("PING latency=%+v", ping)
+	}
+	if l := p.Latency("hgetall"); l == nil || l.Count != 3 {
+		t.Errorf("latency=%+v", l)
+	}
+	if p.Latency("GET") != nil {
+		t.Error("GET is not called")
+	}
+}
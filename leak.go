@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// the tick of the leak detection for the tiny thresholds
+const minLeakCheckInterval = time.Millisecond
+
+// record of a conn popped and not pushed back yet
+type leak struct {
+	stack    []byte // where the conn is popped
+	popped   time.Time
+	reported bool // held beyond the threshold and reported
+}
+
+// record the stack of the conn popped if the leak detection is enabled
+func (p *Pool) track(c *Conn) {
+	if p.opt.leakThreshold <= 0 {
+		return
+	}
+	rec := &leak{stack: debug.Stack(), popped: time.Now()}
+	p.mu.Lock()
+	p.held[rec] = struct{}{}
+	p.mu.Unlock()
+	c.leak = rec
+}
+
+// the conn is pushed back
+func (p *Pool) untrack(c *Conn) {
+	if c.leak == nil {
+		return
+	}
+	p.mu.Lock()
+	delete(p.held, c.leak)
+	p.mu.Unlock()
+	c.leak = nil
+}
+
+// report the conns held beyond threshold every half of threshold until Close,
+// minLeakCheckInterval at least
+func (p *Pool) detectLeaks(threshold time.Duration) {
+	defer p.maintainWg.Done()
+	interval := threshold / 2
+	if interval < minLeakCheckInterval {
+		interval = minLeakCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkLeaks(threshold)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *Pool) checkLeaks(threshold time.Duration) {
+	var leaks []*leak
+	p.mu.Lock()
+	for rec := range p.held {
+		if !rec.reported && time.Since(rec.popped) > threshold {
+			rec.reported = true
+			leaks = append(leaks, rec)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, rec := range leaks {
+		atomic.AddInt64(&p.stats.leak, 1)
+		p.opt.getLogger().Warn("conn held too long", "addr", p.Address, "held", time.Since(rec.popped), "stack", string(rec.stack))
+	}
+}
+
+// set to the conns dialed by the pool with the leak detection
+func (p *Pool) setFinalizer(c *Conn) {
+	runtime.SetFinalizer(c, p.finalize)
+}
+
+// the conn is garbage collected without pushed back, close it and release the slot
+func (p *Pool) finalize(c *Conn) {
+	rec := c.leak
+	if rec == nil {
+		return
+	}
+	p.mu.Lock()
+	_, held := p.held[rec]
+	if held {
+		delete(p.held, rec)
+		p.release()
+	}
+	p.mu.Unlock()
+	if !held {
+		return
+	}
+	if !rec.reported {
+		atomic.AddInt64(&p.stats.leak, 1)
+	}
+	p.opt.getLogger().Error("conn garbage collected without push", "addr", p.Address, "held", time.Since(rec.popped), "stack", string(rec.stack))
+	c.Close()
+}
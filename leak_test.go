@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"bytes"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bytes.Buffer written by the detecting goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLeakHeldTooLong(t *testing.T) {
+	s := newFakeServer(t, nil)
+	var buf syncBuffer
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithLeakDetection(20*time.Millisecond),
+		WithLogger(NewPrintfLogger(log.New(&buf, "", 0), LevelDebug)))
+	defer p.Close()
+
+	c := p.Pop()
+	for i := 0; i < 100 && p.Snapshot().LeakNum == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := p.Snapshot().LeakNum; n != 1 {
+		t.Fatalf("leak should be reported once, leak=%d", n)
+	}
+	log := buf.String()
+	if !strings.Contains(log, "WARN conn held too long addr="+s.Addr()) || !strings.Contains(log, "TestLeakHeldTooLong") {
+		t.Errorf("log=%s", log)
+	}
+
+	p.Push(c)
+	c = p.Pop()
+	p.Push(c)
+	time.Sleep(50 * time.Millisecond)
+	if n := p.Snapshot().LeakNum; n != 1 {
+		t.Errorf("conn pushed back should not be reported, leak=%d", n)
+	}
+}
+
+func TestLeakTinyThreshold(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithLeakDetection(1), WithLogger(nopLogger{}))
+	c := p.Pop()
+	for i := 0; i < 100 && p.Snapshot().LeakNum == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := p.Snapshot().LeakNum; n != 1 {
+		t.Errorf("leak=%d", n)
+	}
+	p.Push(c)
+	p.Close()
+}
+
+// pop and forget to push back
+func leakConn(p *Pool) {
+	c := p.Pop()
+	c.Call("PING")
+}
+
+func TestLeakGarbageCollected(t *testing.T) {
+	s := newFakeServer(t, nil)
+	var buf syncBuffer
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithLeakDetection(time.Hour), WithMaxConnPerServer(1),
+		WithLogger(NewPrintfLogger(log.New(&buf, "", 0), LevelDebug)))
+	defer p.Close()
+
+	leakConn(p)
+	for i := 0; i < 100 && p.Snapshot().LeakNum == 0; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := p.Snapshot().LeakNum; n != 1 {
+		t.Fatalf("leak=%d", n)
+	}
+	if !strings.Contains(buf.String(), "ERROR conn garbage collected without push") || !strings.Contains(buf.String(), "leakConn") {
+		t.Errorf("log=%s", buf.String())
+	}
+	// the slot is released
+	if p.Actives() != 0 {
+		t.Errorf("active=%d", p.Actives())
+	}
+	c := p.Pop()
+	if c == nil {
+		t.Fatal("pop failed")
+	}
+	p.Push(c)
+}
@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Logger receive the events of pools and conns with key/value pairs, *slog.Logger satisfy it
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// Printer is the Printf of *log.Logger
+type Printer interface {
+	Printf(format string, v ...interface{})
+}
+
+var (
+	defaultLogger Logger = nopLogger{}
+	loggerMu      sync.RWMutex
+)
+
+// set the logger of the pools without WithLogger and the conns without pool, nil to discard the events
+// events are discarded by default
+func SetLogger(logger Logger) {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	loggerMu.Lock()
+	defaultLogger = logger
+	loggerMu.Unlock()
+}
+
+func getLogger() Logger {
+	loggerMu.RLock()
+	logger := defaultLogger
+	loggerMu.RUnlock()
+	return logger
+}
+
+// the logger of option if set, or the logger of SetLogger
+func (o *option) getLogger() Logger {
+	if o != nil && o.logger != nil {
+		return o.logger
+	}
+	return getLogger()
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keyvals ...interface{}) {}
+func (nopLogger) Info(msg string, keyvals ...interface{})  {}
+func (nopLogger) Warn(msg string, keyvals ...interface{})  {}
+func (nopLogger) Error(msg string, keyvals ...interface{}) {}
+
+// Logger print the events of level not lower than min by p, in line like
+// WARN wait timeout addr=127.0.0.1:6379 timeout=3s
+func NewPrintfLogger(p Printer, min LogLevel) Logger {
+	return &printfLogger{p: p, min: min}
+}
+
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+func (l LogLevel) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+	}
+	return levelNames[l]
+}
+
+type printfLogger struct {
+	p   Printer
+	min LogLevel
+}
+
+func (l *printfLogger) Debug(msg string, keyvals ...interface{}) { l.log(LevelDebug, msg, keyvals) }
+func (l *printfLogger) Info(msg string, keyvals ...interface{})  { l.log(LevelInfo, msg, keyvals) }
+func (l *printfLogger) Warn(msg string, keyvals ...interface{})  { l.log(LevelWarn, msg, keyvals) }
+func (l *printfLogger) Error(msg string, keyvals ...interface{}) { l.log(LevelError, msg, keyvals) }
+
+func (l *printfLogger) log(level LogLevel, msg string, keyvals []interface{}) {
+	if level < l.min {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(level.String() + " " + msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 < len(keyvals) {
+			fmt.Fprintf(&b, " %v=%v", keyvals[i], keyvals[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", keyvals[i])
+		}
+	}
+	l.p.Printf("%s", b.String())
+}
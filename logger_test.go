@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+var _ Logger = (*slog.Logger)(nil)
+
+func TestSlogLogger(t *testing.T) {
+	s := newFakeServer(t, nil)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithLogger(logger), WithMaxConnPerServer(1), WithWaitTimeout(10*time.Millisecond))
+
+	c := p.Pop()
+	if _, e := p.PopContext(context.Background()); e != ErrWaitTimeout {
+		t.Fatalf("e=%v", e)
+	}
+	p.Push(c)
+	if !strings.Contains(buf.String(), `level=WARN msg="wait timeout" addr=`+s.Addr()+" timeout=10ms max_conns=1") {
+		t.Errorf("log=%s", buf.String())
+	}
+
+	buf.Reset()
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+	p, _ = NewPoolURL("redis://"+addr, WithLogger(logger))
+	if _, e := p.PopContext(context.Background()); e == nil {
+		t.Fatal("dial should fail")
+	}
+	if !strings.Contains(buf.String(), `level=WARN msg="dial failed" addr=`+addr+" err=") {
+		t.Errorf("log=%s", buf.String())
+	}
+}
+
+func TestPrintfLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewPrintfLogger(log.New(&buf, "", 0), LevelInfo)
+	logger.Debug("discarded", "a", 1)
+	logger.Warn("wait timeout", "addr", "127.0.0.1:6379", "timeout", time.Second, "odd")
+	if buf.String() != "WARN wait timeout addr=127.0.0.1:6379 timeout=1s odd\n" {
+		t.Errorf("log=%q", buf.String())
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(NewPrintfLogger(log.New(&buf, "", 0), LevelDebug))
+	defer SetLogger(nil)
+
+	p := NewPool("127.0.0.1:6379", "", 1, 1, 60)
+	p.Push(nil)
+	Debug("legacy", "127.0.0.1:6380")
+	if buf.String() != "DEBUG push nil conn addr=127.0.0.1:6379\nDEBUG legacy addr=127.0.0.1:6380\n" {
+		t.Errorf("log=%q", buf.String())
+	}
+
+	SetLogger(nil)
+	buf.Reset()
+	p.Push(nil)
+	if buf.Len() != 0 {
+		t.Errorf("log=%q", buf.String())
+	}
+}
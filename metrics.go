@@ -0,0 +1,144 @@
+package redis
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// content type of prometheus text exposition format
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// http handler of the metrics of the pools got on each request, in prometheus text format
+func MetricsHandler(pools func() []*Pool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MetricsContentType)
+		WriteMetrics(w, pools())
+	})
+}
+
+// metrics of the master and slave pools of all servers
+func (client *Client) MetricsHandler() http.Handler {
+	return MetricsHandler(client.Pools)
+}
+
+// master and slave pools of all servers
+func (client *Client) Pools() []*Pool {
+	var pools []*Pool
+	for _, cd := range client.Drivers() {
+		pools = append(pools, cd.Pools()...)
+	}
+	return pools
+}
+
+type metric struct {
+	name  string
+	kind  string // counter or gauge
+	help  string
+	value func(info *PoolInfo) float64
+}
+
+var poolMetrics = []metric{
+	{"redis_pool_idle_conns", "gauge", "Idle connections in the pool.",
+		func(info *PoolInfo) float64 { return float64(info.IdleNum) }},
+	{"redis_pool_active_conns", "gauge", "Connections in use.",
+		func(info *PoolInfo) float64 { return float64(info.ActiveNum) }},
+	{"redis_pool_conns_created_total", "counter", "Connections dialed.",
+		func(info *PoolInfo) float64 { return float64(info.CreateNum) }},
+	{"redis_pool_conns_create_failed_total", "counter", "Connections failed to dial.",
+		func(info *PoolInfo) float64 { return float64(info.CreateFailedNum) }},
+	{"redis_pool_conns_closed_total", "counter", "Connections closed.",
+		func(info *PoolInfo) float64 { return float64(info.CloseNum) }},
+	{"redis_pool_conns_evicted_total", "counter", "Idle connections closed by the health check.",
+		func(info *PoolInfo) float64 { return float64(info.EvictNum) }},
+	{"redis_pool_conns_expired_total", "counter", "Connections closed beyond the max lifetime.",
+		func(info *PoolInfo) float64 { return float64(info.ExpireNum) }},
+	{"redis_pool_conns_leaked_total", "counter", "Connections held too long or garbage collected without pushed back.",
+		func(info *PoolInfo) float64 { return float64(info.LeakNum) }},
+	{"redis_pool_conn_age_seconds_total", "counter", "Total lifetime of the closed connections.",
+		func(info *PoolInfo) float64 { return info.ConnAge.Seconds() }},
+	{"redis_pool_waits_total", "counter", "Times of waiting for a connection.",
+		func(info *PoolInfo) float64 { return float64(info.WaitNum) }},
+	{"redis_pool_wait_seconds_total", "counter", "Total time of waiting for a connection.",
+		func(info *PoolInfo) float64 { return info.WaitDuration.Seconds() }},
+	{"redis_pool_wait_timeouts_total", "counter", "Waits failed by timeout or too many waiters.",
+		func(info *PoolInfo) float64 { return float64(info.TimeoutNum) }},
+	{"redis_pool_calls_total", "counter", "Commands called.",
+		func(info *PoolInfo) float64 { return float64(info.CallNum) }},
+	{"redis_pool_call_net_errors_total", "counter", "Commands failed by network errors.",
+		func(info *PoolInfo) float64 { return float64(info.CallNetErrNum) }},
+	{"redis_pool_ping_errors_total", "counter", "PING failed.",
+		func(info *PoolInfo) float64 { return float64(info.PingErrNum) }},
+}
+
+// write the metrics of pools in prometheus text format
+func WriteMetrics(w io.Writer, pools []*Pool) error {
+	bw := bufio.NewWriter(w)
+	infos := make([]*PoolInfo, len(pools))
+	for i, p := range pools {
+		infos[i] = p.Snapshot()
+	}
+	for _, m := range poolMetrics {
+		writeHeader(bw, m.name, m.kind, m.help)
+		for _, info := range infos {
+			writeSample(bw, m.name, labels("addr", info.Address), m.value(info))
+		}
+	}
+
+	name := "redis_command_duration_seconds"
+	writeHeader(bw, name, "histogram", "Latency of the commands.")
+	for _, p := range pools {
+		histograms := p.stats.histograms()
+		commands := make([]string, 0, len(histograms))
+		for command := range histograms {
+			commands = append(commands, command)
+		}
+		sort.Strings(commands)
+		for _, command := range commands {
+			writeHistogram(bw, name, labels("addr", p.Address, "command", command), histograms[command])
+		}
+	}
+	return bw.Flush()
+}
+
+func writeHeader(w *bufio.Writer, name, kind, help string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// cumulative buckets with le, sum and count of the histogram
+func writeHistogram(w *bufio.Writer, name, labels string, h *histogram) {
+	var count int64
+	for i := 0; i < latencyBuckets-1; i++ {
+		count += atomic.LoadInt64(&h.counts[i])
+		le := strconv.FormatFloat(bucketBound(i).Seconds(), 'g', -1, 64)
+		writeSample(w, name+"_bucket", labels+`,le="`+le+`"`, float64(count))
+	}
+	count += atomic.LoadInt64(&h.counts[latencyBuckets-1])
+	writeSample(w, name+"_bucket", labels+`,le="+Inf"`, float64(count))
+	writeSample(w, name+"_sum", labels, time.Duration(atomic.LoadInt64(&h.sum)).Seconds())
+	writeSample(w, name+"_count", labels, float64(count))
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	w.WriteString(name + "{" + labels + "} " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels like addr="127.0.0.1:6379",command="GET" of name and value pairs
+func labels(kv ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(kv[i] + `="` + labelEscaper.Replace(kv[i+1]) + `"`)
+	}
+	return b.String()
+}
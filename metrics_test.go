@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"bytes"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestMetricsHandler(t *testing.T) {
+	p1 := NewPool("127.0.0.1:6379", "", 10, 5, 60)
+	p1.IdleNum, p1.ActiveNum = 2, 1
+	p1.stats.create = 4
+	p1.stats.createFailed = 1
+	p1.stats.close = 1
+	p1.stats.connAge = int64(90 * time.Second)
+	p1.stats.wait = 3
+	p1.stats.waitDuration = int64(1500 * time.Millisecond)
+	p1.stats.waitTimeout = 1
+	p1.stats.call = 100
+	p1.stats.callNetErr = 2
+	p1.stats.observe("get", 300*time.Microsecond)
+	p1.stats.observe("GET", 3*time.Millisecond)
+	p1.stats.observe("HGETALL", 2*time.Second)
+	p2 := NewPool(`127.0.0.1:"6380"`, "", 10, 5, 60)
+	p2.stats.evict = 3
+	p2.stats.expire = 2
+	p2.stats.leak = 1
+	p2.stats.pingErr = 1
+
+	w := httptest.NewRecorder()
+	MetricsHandler(func() []*Pool { return []*Pool{p1, p2} }).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != MetricsContentType {
+		t.Errorf("content type=%s", ct)
+	}
+
+	golden := "testdata/metrics.golden"
+	if *update {
+		if e := os.WriteFile(golden, w.Body.Bytes(), 0644); e != nil {
+			t.Fatal(e)
+		}
+	}
+	want, e := os.ReadFile(golden)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(w.Body.Bytes(), want) {
+		t.Errorf("metrics differ from %s, run go test -update to see the diff\n%s", golden, w.Body.String())
+	}
+}
+
+func TestClientMetricsHandler(t *testing.T) {
+	s := newFakeServer(t, nil)
+	client, _ := NewClient([]string{s.Addr()})
+	defer client.Close()
+	client.Driver("").Call("SET", "a", "1")
+
+	w := httptest.NewRecorder()
+	client.MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, line := range []string{
+		`redis_pool_idle_conns{addr="` + s.Addr() + `"} 1`,
+		`redis_pool_calls_total{addr="` + s.Addr() + `"} 1`,
+		`redis_command_duration_seconds_count{addr="` + s.Addr() + `",command="SET"} 1`,
+	} {
+		if !bytes.Contains([]byte(body), []byte(line+"\n")) {
+			t.Errorf("no %s in\n%s", line, body)
+		}
+	}
+}
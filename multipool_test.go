@@ -1,178 +1,269 @@
 package redis
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"runtime"
-	// "strconv"
-	"math/rand"
-	"sync"
+	"strconv"
+	"strings"
 	"testing"
-	"time"
 )
 
-func init() {
-	runtime.GOMAXPROCS(runtime.NumCPU())
-}
+const shardKeys = 100000
 
-func TestMultiOnce(t *testing.T) {
-	addresses := []string{"10.16.15.121:9991:1234567890"}
-	addr := "10.16.15.121:9991:1234567890"
-	mp := NewMultiPool(addresses, 50, 20, 9)
+func shardAddrs(n int) []string {
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("10.0.0.%d:6379", i+1)
+	}
+	return addrs
+}
 
-	go func() {
-		for {
-			fmt.Println("-----------" + mp.Info())
-		}
-	}()
+// server of each key
+func placement(mp *MultiPool) []string {
+	servers := make([]string, shardKeys)
+	for i := range servers {
+		servers[i] = mp.ServerByKey("key:" + strconv.Itoa(i))
+	}
+	return servers
+}
 
-	time.Sleep(time.Second)
+func TestMultiPoolAddDel(t *testing.T) {
+	mp := NewMultiPool(shardAddrs(10), 10, 10, 10)
+	defer mp.Close()
+	before := placement(mp)
 
-	for i := 0; i < 100; i++ {
-		mp.Call(addr).SET("a", "a")
+	counts := make(map[string]int)
+	for _, server := range before {
+		counts[server]++
+	}
+	for _, addr := range mp.Servers() {
+		if n := counts[addr]; n < shardKeys/10*7/10 || n > shardKeys/10*13/10 {
+			t.Errorf("unbalanced %s=%d", addr, n)
+		}
 	}
 
-	time.Sleep(time.Second)
+	// only the keys going to the new server move, about 1/11
+	if _, ok := mp.AddPool("10.0.0.11:6379"); !ok {
+		t.Fatal("add failed")
+	}
+	after := placement(mp)
+	moved := 0
+	for i := range before {
+		if before[i] != after[i] {
+			moved++
+			if after[i] != "10.0.0.11:6379" {
+				t.Fatalf("key:%d moved from %s to %s", i, before[i], after[i])
+			}
+		}
+	}
+	if moved < shardKeys/11*7/10 || moved > shardKeys/11*13/10 {
+		t.Errorf("add moved %d keys", moved)
+	}
 
-	for i := 0; i < 100; i++ {
-		c := mp.PopByAddr(addr)
-		if c != nil {
-			c.SET("a", "a")
-		} else {
-			fmt.Println("c==nil")
+	// only the keys of the server removed move
+	mp.DelPool("10.0.0.3:6379")
+	deleted := placement(mp)
+	moved = 0
+	for i := range after {
+		if after[i] != deleted[i] {
+			moved++
+			if after[i] != "10.0.0.3:6379" {
+				t.Fatalf("key:%d of %s moved", i, after[i])
+			}
 		}
-		mp.Push(c)
+	}
+	if moved == 0 {
+		t.Error("no key moved")
+	}
+	if len(mp.Servers()) != 10 || mp.Pool("10.0.0.3:6379") != nil {
+		t.Errorf("servers=%v", mp.Servers())
 	}
 
-	time.Sleep(10e9)
+	// removing the server added gets back the first placement
+	mp.DelPool("10.0.0.11:6379")
+	mp.AddPool("10.0.0.3:6379")
+	for i, server := range placement(mp) {
+		if server != before[i] {
+			t.Fatalf("key:%d on %s, was %s", i, server, before[i])
+		}
+	}
 }
 
-func TestMultiPush(t *testing.T) {
-	addresses := []string{"10.16.15.121:9931:1234567890", "10.16.15.121:9991:1234567890"}
-	addr := "10.16.15.121:9991:1234567890"
-	// addr := "10.16.15.121:9731"
-	mp := NewMultiPool(addresses, 50, 20, 9)
-
-	c := mp.PopByAddr(addr)
-	time.Sleep(1 * time.Second)
-	mp.Push(c)
+func TestMultiPoolPlacementByHost(t *testing.T) {
+	addrs := shardAddrs(5)
+	mp := NewMultiPool(addrs, 10, 10, 10)
+	defer mp.Close()
+	before := placement(mp)
 
-	// fmt.Println(mp.AddPool("10.16.15.121:9901", 10, 60))
-	// fmt.Println(mp.servers)
-	// fmt.Println(mp.pools)
-	// fmt.Println(mp.Info())
-	// go func() {
-	// 	for i := 0; i < 29; i++ {
-	// 		fmt.Println(mp.Info())
-	// 		time.Sleep(time.Second)
-	// 	}
-	// }()
-
-	// for i := 0; i < 50; i++ {
-	// 	go func() {
-	// 		c := mp.PopByAddr(addr)
-	// 		time.Sleep(1 * time.Second)
-	// 		mp.Push(c)
-	// 	}()
-	// }
-	time.Sleep(30e9)
+	// only the password or the format of the addresses changed
+	changed := []string{addrs[0] + ":pass", "redis://:pass@" + addrs[1], "redis://" + addrs[2] + "/0", addrs[3], addrs[4] + ":other"}
+	mp2 := NewMultiPool(changed, 10, 10, 10)
+	defer mp2.Close()
+	for i, server := range placement(mp2) {
+		if server != before[i] {
+			t.Fatalf("key:%d moved from %s to %s", i, before[i], server)
+		}
+	}
 }
 
-func TestMultiPop(t *testing.T) {
-	addresses := []string{"10.16.15.121:9731", "10.16.15.121:9991:1234567890"}
-	// addr := "10.16.15.121:9991@1234567890"
-	addr := "10.16.15.121:9731"
-	mp := NewMultiPool(addresses, 50, 20, 9)
-	fmt.Println(mp.AddPool("10.16.15.121:9901", 10, 20, 60))
-	fmt.Println(mp.servers)
-	go func() {
-		for i := 0; i < 29; i++ {
-			fmt.Println(mp.Info())
-			// time.Sleep(time.Second)
-			mp.AddPool("10.16.15.121:9901", 10, 10, 60)
-			// mp.DelPool("10.16.15.121:9901")
-			mp.ReplacePool("10.16.15.121:9901", "10.16.15.121:9801", 20, 10, 8)
+func TestMultiPoolReplace(t *testing.T) {
+	mp := NewMultiPool(shardAddrs(5), 10, 10, 10)
+	defer mp.Close()
+	before := placement(mp)
+
+	if !mp.ReplacePool("10.0.0.2:6379", "10.0.1.2:6380:pass", 20, 10, 10) {
+		t.Fatal("replace failed")
+	}
+	for i, server := range placement(mp) {
+		want := before[i]
+		if want == "10.0.0.2:6379" {
+			want = "10.0.1.2:6380"
 		}
-	}()
+		if server != want {
+			t.Fatalf("key:%d on %s, want %s", i, server, want)
+		}
+	}
+	p := mp.Pool("10.0.1.2:6380:pass")
+	if p == nil || p.Password != "pass" || p.MaxConnNum != 20 {
+		t.Fatalf("pool=%+v", p)
+	}
+	if mp.ReplacePool("10.0.0.2:6379", "10.0.2.2:6379", 10, 10, 10) {
+		t.Error("replace of removed server")
+	}
+	if mp.ReplacePool("10.0.0.1:6379", "10.0.1.2:6380", 10, 10, 10) {
+		t.Error("replace by existing server")
+	}
+	// the name is kept by the replacement
+	if _, ok := mp.AddPool("10.0.0.2:6379"); ok {
+		t.Error("add of the name on the ring")
+	}
+}
 
-	// }
-	for i := 0; i < 50; i++ {
-		go func() {
-			c := mp.PopByAddr(addr)
-			if c == nil {
-				t.Error("c==nil....................")
-				return
-			}
+func TestMultiPoolWeight(t *testing.T) {
+	mp, e := NewMultiPoolOptions(shardAddrs(3), WithShardWeight("10.0.0.1:6379", 2))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer mp.Close()
+	counts := make(map[string]int)
+	for _, server := range placement(mp) {
+		counts[server]++
+	}
+	// 2:1:1
+	if n := counts["10.0.0.1:6379"]; n < shardKeys/2*8/10 || n > shardKeys/2*12/10 {
+		t.Errorf("weight 2 got %d keys", n)
+	}
 
-			fmt.Println(c.SET("key", "value"))
-			mp.Push(c)
-		}()
-		time.Sleep(time.Duration(5e9))
+	if _, e := NewMultiPoolOptions([]string{"10.0.0.1:6379", "bad"}); e == nil {
+		t.Error("bad address accepted")
+	}
+	if _, e := NewMultiPoolOptions([]string{"redis://:secret@10.0.0.1/db"}); e == nil || strings.Contains(e.Error(), "secret") {
+		t.Errorf("e=%v", e)
+	}
+	if _, e := NewMultiPoolOptions(nil); e != ErrNoServer {
+		t.Errorf("e=%v", e)
 	}
 }
 
-func TestMultiPool(t *testing.T) {
-	addresses := []string{"10.16.15.121:9731", "10.16.15.121:9991:1234567890"}
-	addr := "10.16.15.121:9991:1234567890"
-	mp := NewMultiPool(addresses, 20, 10, 20)
-	fmt.Println(mp.AddPool("10.16.15.121:9901", 10, 10, 60))
-	go func() {
-		for i := 0; i < 29; i++ {
-			mp.Info()
-			time.Sleep(time.Second)
+func TestMultiPoolConn(t *testing.T) {
+	s1 := newFakeServer(t, nil)
+	s2 := newFakeServer(t, nil)
+	mp, e := NewMultiPoolOptions([]string{s1.Addr(), s2.Addr()})
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer mp.Close()
+
+	for i := 0; i < 20; i++ {
+		key := "key:" + strconv.Itoa(i)
+		c := mp.PopByKey(key)
+		if c == nil {
+			t.Fatal("pop nil")
 		}
-	}()
-
-	var g sync.WaitGroup
-	for i := 0; i < 50; i++ {
-		g.Add(1)
-		go func() {
-			defer g.Done()
-			time.Sleep(1000)
-			c := mp.PopByAddr(addr)
-			if c == nil {
-				t.Error("c==nil....................")
-				return
+		if c.Address != mp.ServerByKey(key) {
+			t.Fatalf("%s popped from %s", key, c.Address)
+		}
+		if _, e := c.Call("SET", key, key); e != nil {
+			t.Fatal(e)
+		}
+		mp.PushByKey(key, c)
+	}
+	for i := 0; i < 20; i++ {
+		key := "key:" + strconv.Itoa(i)
+		e := mp.WithConn(context.Background(), key, func(c *Conn) error {
+			v, e := c.Call("GET", key)
+			if e == nil && string(v.([]byte)) != key {
+				t.Errorf("GET %s=%s", key, v)
 			}
-			// c.PipeSend("set", strconv.Itoa(i), strconv.Itoa(i))
-			// c.PipeExec()
-			// fmt.Println("PING")
-			n := rand.Intn(10)
-			c.CallN(3, "PING")
-			time.Sleep(time.Duration(n * 1e9))
-			// mp.PushByAddr(addr, c)
-			mp.Push(c)
-		}()
+			return e
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	if n := mp.Pool(s1.Addr()).Actives() + mp.Pool(s2.Addr()).Actives(); n != 0 {
+		t.Errorf("active=%d, conns should be pushed back", n)
+	}
+
+	// the server is added by WithConnByAddr
+	s3 := newFakeServer(t, nil)
+	if e := mp.WithConnByAddr(context.Background(), s3.Addr(), func(c *Conn) error {
+		_, e := c.Call("SET", "a", "1")
+		return e
+	}); e != nil || s3.DB(0)["a"] != "1" || len(mp.Servers()) != 3 {
+		t.Errorf("e=%v servers=%v", e, mp.Servers())
+	}
+	if e := mp.WithConnByAddr(context.Background(), "bad", func(c *Conn) error { return nil }); e == nil {
+		t.Error("bad address accepted")
+	}
+	mp.DelPool(s3.Addr())
+
+	// conn popped before the server removed goes back to the closing pool
+	c := mp.PopByAddr(s1.Addr())
+	if c == nil {
+		t.Fatal("pop nil")
+	}
+	p := mp.Pool(s1.Addr())
+	mp.DelPool(s1.Addr())
+	mp.Push(c)
+	if p.Close() != nil {
+		t.Error("conn not pushed back to the removed pool")
+	}
+
+	var infos []*PoolInfo
+	if e := json.Unmarshal([]byte(mp.Info()), &infos); e != nil || len(infos) != 1 || infos[0].Address != s2.Addr() {
+		t.Errorf("info=%s e=%v", mp.Info(), e)
+	}
+	if e := mp.Close(); e != nil {
+		t.Error(e)
+	}
+	if mp.PopByKey("key") != nil {
+		t.Error("pop after closed")
 	}
-	g.Wait()
-	time.Sleep(30e9)
 }
 
-func TestPushAndPop(t *testing.T) {
-	addresses := []string{"10.16.15.121:9731", "10.16.15.121:9991:1234567890"}
-	addr := "10.16.15.121:9991:1234567890"
-	mp := NewMultiPool(addresses, 20, 10, 20)
+func TestMultiPoolPassword(t *testing.T) {
+	s1, s2 := newFakeServer(t, nil), newFakeServer(t, nil)
+	mp := NewMultiPool([]string{"redis://:pass@" + s1.Addr(), s2.Addr() + ":pass"}, 2, 2, 60)
+	defer mp.Close()
 
-	start := time.Now()
-	for i := 0; i < 10000; i++ {
-		c := mp.PopByAddr(addr)
-		if c == nil {
-			t.Error("c==nil....................")
-			return
+	// the conns go back to the pools keyed by host:port
+	for i := 0; i < 5; i++ {
+		for _, s := range []*fakeServer{s1, s2} {
+			c := mp.PopByAddr(s.Addr())
+			if c == nil {
+				t.Fatalf("pop %s nil at %d", s.Addr(), i)
+			}
+			mp.Push(c)
 		}
-		c.SET("a", "a")
-		mp.Push(c)
 	}
-	fmt.Println("push and pop costs=", time.Now().Sub(start).String())
-
-	start = time.Now()
-	c := mp.PopByAddr(addr)
-	for i := 0; i < 10000; i++ {
-		if c == nil {
-			t.Error("c==nil....................")
-			return
+	for _, p := range mp.Pools() {
+		if p.Actives() != 0 || p.Idles() != 1 {
+			t.Errorf("%s active=%d idle=%d", p.Address, p.Actives(), p.Idles())
 		}
-		c.SET("a", "a")
 	}
-	fmt.Println("no push=", time.Now().Sub(start).String())
-
+	if s1.Count("AUTH") != 1 || s2.Count("AUTH") != 1 {
+		t.Errorf("AUTH %d %d", s1.Count("AUTH"), s2.Count("AUTH"))
+	}
 }
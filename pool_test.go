@@ -0,0 +1,533 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolDB(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, e := NewPoolURL("redis://" + s.Addr() + "/2")
+	if e != nil {
+		t.Fatal(e)
+	}
+	c := p.Pop()
+	if c == nil {
+		t.Fatal("pop nil")
+	}
+	if c.DB() != 2 {
+		t.Fatalf("db=%d", c.DB())
+	}
+	if _, e := c.Call("SET", "key", "db2"); e != nil {
+		t.Fatal(e)
+	}
+	if _, e := c.Call("SELECT", 5); e != nil || c.DB() != 5 {
+		t.Fatalf("select e=%v db=%d", e, c.DB())
+	}
+	p.Push(c)
+
+	c = p.Pop()
+	if c.DB() != 2 {
+		t.Fatalf("db not reset, db=%d", c.DB())
+	}
+	v, e := c.Call("GET", "key")
+	if e != nil || string(v.([]byte)) != "db2" {
+		t.Errorf("GET=%v e=%v", v, e)
+	}
+	p.Push(c)
+	if p.Snapshot().CreateNum != 1 {
+		t.Errorf("conn should be reused, CreateNum=%d", p.Snapshot().CreateNum)
+	}
+}
+
+func TestPoolResetDirty(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p := NewPool(s.Addr(), "", 10, 10, 10)
+
+	// unfinished transaction and watch are cleared
+	c := p.Pop()
+	if e := c.Watch([]string{"key"}); e != nil {
+		t.Fatal(e)
+	}
+	if e := c.MULTI(); e != nil {
+		t.Fatal(e)
+	}
+	if !c.IsDirty() {
+		t.Fatal("conn should be dirty")
+	}
+	p.Push(c)
+	if s.Count("DISCARD") != 1 {
+		t.Errorf("DISCARD=%d", s.Count("DISCARD"))
+	}
+	c = p.Pop()
+	if c.IsDirty() {
+		t.Error("conn should be reset")
+	}
+
+	// watch only
+	c.Watch([]string{"key"})
+	p.Push(c)
+	if s.Count("UNWATCH") != 1 {
+		t.Errorf("UNWATCH=%d", s.Count("UNWATCH"))
+	}
+
+	// replies of pipeline unread, discard the conn
+	c = p.Pop()
+	c.PipeSend("INCR", "n")
+	p.Push(c)
+	if p.Idles() != 0 || p.Actives() != 0 {
+		t.Errorf("conn should be discarded idle=%d active=%d", p.Idles(), p.Actives())
+	}
+	c = p.Pop()
+	if p.Snapshot().CreateNum != 2 {
+		t.Errorf("CreateNum=%d", p.Snapshot().CreateNum)
+	}
+	p.Push(c)
+}
+
+func TestDriverSelect(t *testing.T) {
+	s := newFakeServer(t, nil)
+	client, e := NewClient([]string{s.Addr()}, WithDB(3))
+	if e != nil {
+		t.Fatal(e)
+	}
+	cd := client.Driver("")
+	if _, e := cd.SELECT(1); e != ErrPooledSelect {
+		t.Errorf("SELECT e=%v", e)
+	}
+	if _, e := cd.Call("SET", "key", "value"); e != nil {
+		t.Fatal(e)
+	}
+	if s.DB(3)["key"] != "value" {
+		t.Error("key should be set in db 3")
+	}
+}
+
+func TestPoolDBPrecedence(t *testing.T) {
+	s := newFakeServer(t, nil)
+	// db 0 in url is not overridden by WithDB
+	p, _ := NewPoolURL("redis://"+s.Addr()+"/0", WithDB(3))
+	defer p.Close()
+	if e := p.WithConn(context.Background(), func(c *Conn) error {
+		_, e := c.Call("SET", "key", "value")
+		return e
+	}); e != nil {
+		t.Fatal(e)
+	}
+	if s.DB(0)["key"] != "value" || s.Count("SELECT") != 0 {
+		t.Errorf("db0=%v SELECT=%d", s.DB(0), s.Count("SELECT"))
+	}
+}
+
+// wait until n callers are waiting in the pool
+func waitWaiters(t *testing.T, p *Pool, n int) {
+	for i := 0; i < 1000; i++ {
+		p.mu.RLock()
+		waiting := len(p.waiters)
+		p.mu.RUnlock()
+		if waiting == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("waiters not %d", n)
+}
+
+func TestPoolWaitFIFO(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithMaxConnPerServer(1), WithWaitTimeout(0))
+	c := p.Pop()
+
+	order := make(chan int, 3)
+	conns := make(chan *Conn, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			c, e := p.PopContext(context.Background())
+			if e != nil {
+				t.Error(e)
+			}
+			order <- i
+			conns <- c
+		}(i)
+		waitWaiters(t, p, i+1)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		p.Push(c)
+		if n := <-order; n != i {
+			t.Errorf("waiter %d got the conn, want %d", n, i)
+		}
+		c = <-conns
+	}
+	if cost := time.Since(start); cost > 500*time.Millisecond {
+		t.Errorf("waiters should be waked up at once, cost=%v", cost)
+	}
+	p.Push(c)
+
+	info := p.Info()
+	if info.WaitNum != 3 || info.WaitDuration <= 0 || info.CreateNum != 1 || info.IdleNum != 1 || info.ActiveNum != 0 {
+		t.Errorf("info=%+v", info)
+	}
+}
+
+func TestPoolWaitTimeout(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithMaxConnPerServer(1), WithWaitTimeout(50*time.Millisecond), WithMaxWaiters(1))
+	c := p.Pop()
+
+	start := time.Now()
+	if _, e := p.PopContext(context.Background()); e != ErrWaitTimeout {
+		t.Errorf("e=%v", e)
+	}
+	if cost := time.Since(start); cost < 50*time.Millisecond || cost > 500*time.Millisecond {
+		t.Errorf("cost=%v", cost)
+	}
+
+	// the second waiter exceed max waiters
+	done := make(chan error)
+	go func() {
+		_, e := p.PopContext(context.Background())
+		done <- e
+	}()
+	waitWaiters(t, p, 1)
+	if _, e := p.PopContext(context.Background()); e != ErrPoolExhausted {
+		t.Errorf("e=%v", e)
+	}
+	if e := <-done; e != ErrWaitTimeout {
+		t.Errorf("e=%v", e)
+	}
+	if info := p.Info(); info.TimeoutNum != 3 || info.WaitNum != 2 {
+		t.Errorf("info=%+v", info)
+	}
+	p.Push(c)
+}
+
+func TestPoolWaitDiscarded(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithMaxConnPerServer(1))
+	c := p.Pop()
+
+	got := make(chan *Conn)
+	go func() {
+		c, _ := p.PopContext(context.Background())
+		got <- c
+	}()
+	waitWaiters(t, p, 1)
+
+	// the slot of the broken conn is given to the waiter
+	c.err = io.EOF
+	p.Push(c)
+	c = <-got
+	if c == nil || c.err != nil {
+		t.Fatal("waiter should dial a new conn")
+	}
+	if p.Snapshot().CreateNum != 2 || p.Actives() != 1 {
+		t.Errorf("create=%d active=%d", p.Snapshot().CreateNum, p.Actives())
+	}
+	p.Push(c)
+}
+
+func TestPoolCheckIdle(t *testing.T) {
+	var broken sync.Map
+	s := newFakeServer(t, func(sess *fakeSession, args []string) string {
+		if _, ok := broken.Load(sess.conn.RemoteAddr().String()); ok {
+			// close the conn without reply
+			return ""
+		}
+		return fakeRedis(sess, args)
+	})
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithHealthCheck(time.Hour, 1), WithMaxIdleSecondsPerServer(60))
+	defer p.Close()
+
+	conns := []*Conn{p.Pop(), p.Pop(), p.Pop()}
+	// idle too long
+	conns[0].lastActiveTime -= 100
+	// closed by server
+	broken.Store(conns[1].conn.LocalAddr().String(), true)
+	lastActiveTime := conns[2].lastActiveTime - 10
+	conns[2].lastActiveTime = lastActiveTime
+	for _, c := range conns {
+		p.Push(c)
+	}
+
+	p.checkIdle()
+	if p.Idles() != 1 || p.Snapshot().EvictNum != 2 || s.Count("PING") != 1 {
+		t.Fatalf("idle=%d evict=%d ping=%d", p.Idles(), p.Snapshot().EvictNum, s.Count("PING"))
+	}
+	p.checkIdle()
+	if p.Idles() != 1 || p.Snapshot().EvictNum != 2 || s.Count("PING") != 2 {
+		t.Fatalf("idle=%d evict=%d ping=%d", p.Idles(), p.Snapshot().EvictNum, s.Count("PING"))
+	}
+	c := p.Pop()
+	if c != conns[2] || c.lastActiveTime != lastActiveTime {
+		t.Error("PING of health check should not change the last active time")
+	}
+	p.Push(c)
+}
+
+func TestPoolMaintain(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithHealthCheck(10*time.Millisecond, 1), WithMinIdleConnPerServer(2))
+
+	for i := 0; i < 100 && p.Idles() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if p.Idles() != 2 || p.Snapshot().CreateNum != 2 {
+		t.Fatalf("idle=%d create=%d", p.Idles(), p.Snapshot().CreateNum)
+	}
+
+	p.Close()
+	if p.Idles() != 0 {
+		t.Errorf("idle conns should be closed, idle=%d", p.Idles())
+	}
+	pings := s.Count("PING")
+	time.Sleep(50 * time.Millisecond)
+	if s.Count("PING") != pings || p.Snapshot().CreateNum != 2 {
+		t.Error("maintain loop should be stopped")
+	}
+}
+
+func TestPoolMaintainMaxIdle(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithHealthCheck(10*time.Millisecond, 1),
+		WithMinIdleConnPerServer(5), WithMaxIdleConnPerServer(2))
+	defer p.Close()
+
+	// the min idle number is capped by MaxIdleNum
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 100 && p.Idles() < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if p.Idles() != 2 || p.Snapshot().CreateNum != 2 {
+		t.Errorf("idle=%d create=%d", p.Idles(), p.Snapshot().CreateNum)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithMaxConnPerServer(2), WithWaitTimeout(0))
+
+	c1, c2 := p.Pop(), p.Pop()
+	waited := make(chan error, 1)
+	go func() {
+		_, e := p.PopContext(context.Background())
+		waited <- e
+	}()
+	waitWaiters(t, p, 1)
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- p.CloseContext(context.Background())
+	}()
+	if e := <-waited; e != ErrPoolClosed {
+		t.Fatalf("waiter e=%v", e)
+	}
+	if _, e := p.PopContext(context.Background()); e != ErrPoolClosed {
+		t.Fatalf("pop after closed e=%v", e)
+	}
+
+	p.Push(c1)
+	if s.Count("QUIT") != 1 {
+		t.Errorf("conn pushed back should be QUIT, QUIT=%d", s.Count("QUIT"))
+	}
+	select {
+	case e := <-closed:
+		t.Fatalf("closed before conn pushed back e=%v", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	p.Push(c2)
+	if e := <-closed; e != nil {
+		t.Fatal(e)
+	}
+	if s.Count("QUIT") != 2 || p.Actives() != 0 || p.Idles() != 0 {
+		t.Errorf("QUIT=%d active=%d idle=%d", s.Count("QUIT"), p.Actives(), p.Idles())
+	}
+	if e := p.Close(); e != nil {
+		t.Errorf("close again e=%v", e)
+	}
+}
+
+func TestPoolCloseTimeout(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://" + s.Addr())
+
+	c := p.Pop()
+	p.Push(p.Pop())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if e := p.CloseContext(ctx); e != context.DeadlineExceeded {
+		t.Fatalf("e=%v", e)
+	}
+	if s.Count("QUIT") != 1 {
+		t.Errorf("idle conn should be QUIT, QUIT=%d", s.Count("QUIT"))
+	}
+
+	// pushed back after closed
+	p.Push(c)
+	if s.Count("QUIT") != 2 || p.Actives() != 0 {
+		t.Errorf("QUIT=%d active=%d", s.Count("QUIT"), p.Actives())
+	}
+}
+
+func TestPoolMaxConnLifetime(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithMaxConnLifetime(50*time.Millisecond))
+
+	c1, c2 := p.Pop(), p.Pop()
+	p.Push(c1)
+	time.Sleep(60 * time.Millisecond)
+	// expired when pushed back
+	p.Push(c2)
+	if p.Idles() != 1 || p.Actives() != 0 {
+		t.Fatalf("idle=%d active=%d", p.Idles(), p.Actives())
+	}
+	// expired when popped
+	c := p.Pop()
+	if c == c1 || c == c2 {
+		t.Error("expired conn should not be reused")
+	}
+	p.Push(c)
+	if info := p.Snapshot(); info.ExpireNum != 2 || info.CreateNum != 3 || info.CloseNum != 2 {
+		t.Errorf("expire=%d create=%d close=%d", info.ExpireNum, info.CreateNum, info.CloseNum)
+	}
+	if c.createdTime.IsZero() || time.Since(c.createdTime) > time.Second {
+		t.Errorf("created=%v", c.createdTime)
+	}
+}
+
+func TestPoolIdlePolicy(t *testing.T) {
+	s := newFakeServer(t, nil)
+	for _, policy := range []IdlePolicy{IdleFIFO, IdleLIFO} {
+		p, _ := NewPoolURL("redis://"+s.Addr(), WithIdlePolicy(policy))
+		conns := []*Conn{p.Pop(), p.Pop(), p.Pop()}
+		for _, c := range conns {
+			p.Push(c)
+		}
+		want := conns[0]
+		if policy == IdleLIFO {
+			want = conns[2]
+		}
+		c := p.Pop()
+		if c != want {
+			t.Errorf("policy %d got the wrong conn", policy)
+		}
+		p.Push(c)
+		p.Close()
+	}
+}
+
+func TestPoolWithConn(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://" + s.Addr())
+	ctx := context.Background()
+
+	errFn := errors.New("fn failed")
+	var first *Conn
+	e := p.WithConn(ctx, func(c *Conn) error {
+		first = c
+		c.Call("SET", "a", "1")
+		return errFn
+	})
+	if e != errFn || p.Idles() != 1 || p.Actives() != 0 {
+		t.Fatalf("e=%v idle=%d active=%d", e, p.Idles(), p.Actives())
+	}
+
+	poisons := map[string]func(c *Conn){
+		"multi":    func(c *Conn) { c.Call("MULTI") },
+		"pipeline": func(c *Conn) { c.PipeSend("GET", "a") },
+		"broken":   func(c *Conn) { c.conn.Close(); c.Call("GET", "a") },
+	}
+	for name, poison := range poisons {
+		p.WithConn(ctx, func(c *Conn) error {
+			if c != first {
+				t.Errorf("%s: idle conn should be reused", name)
+			}
+			poison(c)
+			return nil
+		})
+		if p.Idles() != 0 || p.Actives() != 0 {
+			t.Fatalf("%s: poisoned conn should be discarded, idle=%d active=%d", name, p.Idles(), p.Actives())
+		}
+		first = p.Pop()
+		p.Push(first)
+	}
+	if s.Count("DISCARD") != 0 {
+		t.Error("MULTI should not be discarded for reuse")
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "panic in fn" {
+				t.Errorf("recovered %v", r)
+			}
+		}()
+		p.WithConn(ctx, func(c *Conn) error {
+			panic("panic in fn")
+		})
+	}()
+	if p.Idles() != 0 || p.Actives() != 0 {
+		t.Errorf("idle=%d active=%d", p.Idles(), p.Actives())
+	}
+
+	p.Close()
+	if e := p.WithConn(ctx, func(c *Conn) error { return nil }); e != ErrPoolClosed {
+		t.Errorf("e=%v", e)
+	}
+}
+
+func TestPoolPipeTimeout(t *testing.T) {
+	s := newFakeServer(t, func(sess *fakeSession, args []string) string {
+		if args[0] == "SLOW" {
+			time.Sleep(100 * time.Millisecond)
+			return "+SLOW\r\n"
+		}
+		return fakeRedis(sess, args)
+	})
+	p, _ := NewPoolURL("redis://" + s.Addr() + "?read_timeout=20ms")
+	defer p.Close()
+	ctx := context.Background()
+
+	e := p.WithConn(ctx, func(c *Conn) error {
+		c.PipeSend("PING")
+		c.PipeSend("SLOW")
+		_, e := c.PipeExec()
+		return e
+	})
+	if e == nil {
+		t.Fatal("pipeline should time out")
+	}
+	// the reply of SLOW is not read by the next caller
+	if p.Idles() != 0 || p.Actives() != 0 {
+		t.Errorf("conn should be discarded idle=%d active=%d", p.Idles(), p.Actives())
+	}
+	e = p.WithConn(ctx, func(c *Conn) error {
+		v, e := c.Call("PING")
+		if e == nil && string(v.([]byte)) != "PONG" {
+			t.Errorf("PING=%s", v)
+		}
+		return e
+	})
+	if e != nil {
+		t.Error(e)
+	}
+}
+
+func TestDriverWithConn(t *testing.T) {
+	s := newFakeServer(t, nil)
+	client, _ := NewClient([]string{s.Addr()})
+	defer client.Close()
+	cd := client.Driver("")
+	e := cd.WithConn(context.Background(), func(c *Conn) error {
+		_, e := c.Call("SET", "a", "1")
+		return e
+	})
+	if e != nil || s.DB(0)["a"] != "1" || cd.mp.Idles() != 1 {
+		t.Errorf("e=%v idle=%d", e, cd.mp.Idles())
+	}
+}
@@ -0,0 +1,215 @@
+package redis
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fake sentinel of mymaster, pushing +switch-master to the subscribers on failover
+type fakeSentinel struct {
+	*fakeServer
+	mu       sync.Mutex
+	master   string
+	replicas []string
+	subs     []*fakeSession
+}
+
+func newFakeSentinel(t *testing.T, master string, replicas ...string) *fakeSentinel {
+	fs := &fakeSentinel{master: master, replicas: replicas}
+	fs.fakeServer = newFakeServer(t, fs.handle)
+	return fs
+}
+
+func (fs *fakeSentinel) handle(sess *fakeSession, args []string) string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "SENTINEL":
+		if args[2] != "mymaster" {
+			return "*-1\r\n"
+		}
+		switch strings.ToLower(args[1]) {
+		case "get-master-addr-by-name":
+			host, port, _ := net.SplitHostPort(fs.master)
+			return arrayReply(bulkReply(host), bulkReply(port))
+		case "replicas":
+			var replies []string
+			for _, replica := range fs.replicas {
+				host, port, _ := net.SplitHostPort(replica)
+				replies = append(replies, arrayReply(bulkReply("name"), bulkReply(replica),
+					bulkReply("ip"), bulkReply(host), bulkReply("port"), bulkReply(port), bulkReply("flags"), bulkReply("slave")))
+			}
+			return arrayReply(replies...)
+		}
+	case "SUBSCRIBE":
+		fs.subs = append(fs.subs, sess)
+		return arrayReply(bulkReply("subscribe"), bulkReply(args[1]), intReply(1))
+	}
+	return fakeRedis(sess, args)
+}
+
+// switch the master, notify the subscribers if notify
+func (fs *fakeSentinel) failover(notify bool, master string, replicas ...string) {
+	fs.mu.Lock()
+	old := fs.master
+	fs.master, fs.replicas = master, replicas
+	subs := fs.subs
+	fs.mu.Unlock()
+	if !notify {
+		return
+	}
+	oldHost, oldPort, _ := net.SplitHostPort(old)
+	host, port, _ := net.SplitHostPort(master)
+	msg := arrayReply(bulkReply("message"), bulkReply("+switch-master"),
+		bulkReply(strings.Join([]string{"mymaster", oldHost, oldPort, host, port}, " ")))
+	for _, sess := range subs {
+		sess.conn.Write([]byte(msg))
+	}
+}
+
+func (fs *fakeSentinel) subscribed() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return len(fs.subs)
+}
+
+// fake redis answering ROLE with the role set
+type fakeNode struct {
+	*fakeServer
+	mu   sync.Mutex
+	role string
+}
+
+func newFakeNode(t *testing.T, role string) *fakeNode {
+	n := &fakeNode{role: role}
+	n.fakeServer = newFakeServer(t, func(sess *fakeSession, args []string) string {
+		if strings.EqualFold(args[0], "ROLE") {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			return arrayReply(bulkReply(n.role), intReply(0), arrayReply())
+		}
+		return fakeRedis(sess, args)
+	})
+	return n
+}
+
+func (n *fakeNode) setRole(role string) {
+	n.mu.Lock()
+	n.role = role
+	n.mu.Unlock()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for " + what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSentinelFailover(t *testing.T) {
+	n1 := newFakeNode(t, "master")
+	n2 := newFakeNode(t, "slave")
+	fs := newFakeSentinel(t, n1.Addr(), n2.Addr())
+	dead := newFakeServer(t, nil)
+	dead.Close()
+
+	cd, e := NewSentinelDriver("mymaster", []string{dead.Addr(), fs.Addr()}, WithReadPolicy(ReadFromSlave))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer cd.Close()
+	if pools := cd.Pools(); len(pools) != 2 || pools[0].Address != n1.Addr() || pools[1].Address != n2.Addr() {
+		t.Fatalf("pools=%v", pools)
+	}
+
+	if _, e := cd.Call("SET", "k", "v"); e != nil {
+		t.Fatal(e)
+	}
+	if n1.DB(0)["k"] != "v" {
+		t.Error("SET not on master")
+	}
+	if _, e := cd.Call("GET", "k"); e != nil || n2.Count("GET") != 1 {
+		t.Errorf("GET not on slave e=%v", e)
+	}
+	if n1.Count("ROLE") != 1 || n2.Count("ROLE") != 1 {
+		t.Errorf("ROLE master=%d slave=%d", n1.Count("ROLE"), n2.Count("ROLE"))
+	}
+
+	waitFor(t, "subscribe", func() bool { return fs.subscribed() == 1 })
+	old := cd.master()
+	n1.setRole("slave")
+	n2.setRole("master")
+	fs.failover(true, n2.Addr(), n1.Addr())
+	waitFor(t, "failover", func() bool { return cd.master().Address == n2.Addr() })
+
+	if _, e := cd.Call("SET", "k", "v2"); e != nil {
+		t.Fatal(e)
+	}
+	if n2.DB(0)["k"] != "v2" || n1.DB(0)["k"] != "v" {
+		t.Error("SET not on the new master")
+	}
+	if sp := cd.slaves(); len(sp) != 1 || sp[0].Address != n1.Addr() {
+		t.Errorf("slaves=%v", sp)
+	}
+	// the old master pool is closed in background
+	waitFor(t, "old master closed", func() bool {
+		c, e := old.PopContext(context.Background())
+		if e == nil {
+			old.Push(c)
+		}
+		return e == ErrPoolClosed
+	})
+}
+
+func TestSentinelRoleCheck(t *testing.T) {
+	n1 := newFakeNode(t, "slave")
+	n2 := newFakeNode(t, "master")
+	fs := newFakeSentinel(t, n1.Addr())
+
+	cd, e := NewSentinelDriver("redis://:pass@mymaster/2", []string{fs.Addr()})
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer cd.Close()
+	if p := cd.master(); p.Password != "pass" || p.address.db != 2 {
+		t.Errorf("master pool=%+v", p)
+	}
+
+	// the failover is not reported, the demoted master is found by ROLE
+	waitFor(t, "subscribe", func() bool { return fs.subscribed() == 1 })
+	fs.failover(false, n2.Addr())
+	if _, e := cd.Call("SET", "k", "v"); e == nil || !strings.HasPrefix(e.Error(), ErrRoleMismatch.Error()) {
+		t.Fatalf("e=%v", e)
+	}
+	waitFor(t, "rediscover", func() bool { return cd.master().Address == n2.Addr() })
+	if _, e := cd.Call("SET", "k", "v"); e != nil {
+		t.Fatal(e)
+	}
+	if n2.DB(2)["k"] != "v" {
+		t.Error("SET not on the new master")
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	fs := newFakeSentinel(t, "127.0.0.1:1")
+	if _, e := NewSentinelDriver("other", []string{fs.Addr()}); e != ErrMasterNotFound {
+		t.Errorf("e=%v", e)
+	}
+	if _, e := NewSentinelDriver("mymaster", nil); e != ErrNoServer {
+		t.Errorf("e=%v", e)
+	}
+	if _, e := NewSentinelDriver("mymaster", []string{"bad"}); e == nil {
+		t.Error("bad sentinel address accepted")
+	}
+	if _, e := NewSentinelDriver("mymaster", []string{"redis://:secret@10.0.0.1/db"}); e == nil || strings.Contains(e.Error(), "secret") {
+		t.Errorf("e=%v", e)
+	}
+}
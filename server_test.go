@@ -0,0 +1,403 @@
+package redis
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// in-process redis server speaking RESP for tests
+type fakeServer struct {
+	ln      net.Listener
+	handler func(sess *fakeSession, args []string) string
+
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	dbs      map[int]map[string]string
+	commands [][]string // all the commands received
+	wg       sync.WaitGroup
+	dmu      sync.Mutex // guard the data of dbs changed by fakeRedis
+}
+
+// state of one client connection
+type fakeSession struct {
+	server  *fakeServer
+	conn    net.Conn
+	db      int
+	name    string
+	multi   [][]string // queued commands after MULTI
+	inMulti bool
+	asking  bool // ASKING received, by the fake cluster
+}
+
+func newFakeServer(t testing.TB, handler func(sess *fakeSession, args []string) string) *fakeServer {
+	ln, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	return serveFake(t, ln, handler)
+}
+
+// serve on ln, handler nil means fakeRedis
+func serveFake(t testing.TB, ln net.Listener, handler func(sess *fakeSession, args []string) string) *fakeServer {
+	if handler == nil {
+		handler = fakeRedis
+	}
+	s := &fakeServer{
+		ln:      ln,
+		handler: handler,
+		conns:   make(map[net.Conn]struct{}),
+		dbs:     make(map[int]map[string]string),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeServer) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// number of commands received with the name
+func (s *fakeServer) Count(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, args := range s.commands {
+		if strings.EqualFold(args[0], name) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *fakeServer) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.commands...)
+}
+
+func (s *fakeServer) Conns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// key value of db
+func (s *fakeServer) DB(db int) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dbs[db] == nil {
+		s.dbs[db] = make(map[string]string)
+	}
+	return s.dbs[db]
+}
+
+// client side of an in-memory conn served by s
+func (s *fakeServer) Pipe() net.Conn {
+	client, server := net.Pipe()
+	s.mu.Lock()
+	s.conns[server] = struct{}{}
+	s.mu.Unlock()
+	s.wg.Add(1)
+	go s.serveConn(server)
+	return client
+}
+
+func (s *fakeServer) serve() {
+	defer s.wg.Done()
+	for {
+		c, e := s.ln.Accept()
+		if e != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(c)
+	}
+}
+
+func (s *fakeServer) serveConn(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		c.Close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+	}()
+	sess := &fakeSession{server: s, conn: c}
+	r := bufio.NewReader(c)
+	for {
+		args, e := readCommand(r)
+		if e != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		s.mu.Unlock()
+
+		reply := s.handler(sess, args)
+		if reply == "" {
+			// no reply, close the connection
+			return
+		}
+		if _, e := io.WriteString(c, reply); e != nil {
+			return
+		}
+		if strings.EqualFold(args[0], "QUIT") {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, e := r.ReadString('\n')
+	if e != nil {
+		return nil, e
+	}
+	n, e := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if e != nil {
+		return nil, e
+	}
+	args := make([]string, n)
+	for i := 0; i < n; i++ {
+		line, e = r.ReadString('\n')
+		if e != nil {
+			return nil, e
+		}
+		size, e := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if e != nil {
+			return nil, e
+		}
+		b := make([]byte, size+2)
+		if _, e = io.ReadFull(r, b); e != nil {
+			return nil, e
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func simpleReply(s string) string { return "+" + s + "\r\n" }
+func errReply(s string) string    { return "-" + s + "\r\n" }
+func intReply(n int64) string     { return ":" + strconv.FormatInt(n, 10) + "\r\n" }
+func nilReply() string            { return "$-1\r\n" }
+
+func bulkReply(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+// array of replies already encoded
+func arrayReply(replies ...string) string {
+	return "*" + strconv.Itoa(len(replies)) + "\r\n" + strings.Join(replies, "")
+}
+
+// a small subset of redis commands
+func fakeRedis(sess *fakeSession, args []string) string {
+	command := strings.ToUpper(args[0])
+	if sess.inMulti {
+		switch command {
+		case "EXEC":
+			sess.inMulti = false
+			replies := make([]string, len(sess.multi))
+			for i, queued := range sess.multi {
+				replies[i] = fakeRedis(sess, queued)
+			}
+			sess.multi = nil
+			return arrayReply(replies...)
+		case "DISCARD":
+			sess.inMulti = false
+			sess.multi = nil
+			return simpleReply("OK")
+		}
+		sess.multi = append(sess.multi, args)
+		return simpleReply("QUEUED")
+	}
+
+	switch command {
+	case "PING":
+		return simpleReply("PONG")
+	case "QUIT", "AUTH", "WATCH", "UNWATCH":
+		return simpleReply("OK")
+	case "HELLO":
+		return arrayReply(bulkReply("server"), bulkReply("redis"), bulkReply("version"), bulkReply("7.2.0"),
+			bulkReply("proto"), intReply(2), bulkReply("id"), intReply(1),
+			bulkReply("mode"), bulkReply("standalone"), bulkReply("role"), bulkReply("master"))
+	case "SELECT":
+		db, e := strconv.Atoi(args[1])
+		if e != nil {
+			return errReply("ERR invalid DB index")
+		}
+		sess.db = db
+		return simpleReply("OK")
+	case "CLIENT":
+		if len(args) == 3 && strings.EqualFold(args[1], "SETNAME") {
+			sess.name = args[2]
+			return simpleReply("OK")
+		}
+		if len(args) == 2 && strings.EqualFold(args[1], "GETNAME") {
+			if sess.name == "" {
+				return nilReply()
+			}
+			return bulkReply(sess.name)
+		}
+		return simpleReply("OK")
+	case "MULTI":
+		sess.inMulti = true
+		return simpleReply("OK")
+	}
+
+	sess.server.dmu.Lock()
+	defer sess.server.dmu.Unlock()
+	switch command {
+	case "GET":
+		v, ok := sess.server.DB(sess.db)[args[1]]
+		if !ok {
+			return nilReply()
+		}
+		return bulkReply(v)
+	case "SET":
+		sess.server.DB(sess.db)[args[1]] = args[2]
+		return simpleReply("OK")
+	case "INCR":
+		db := sess.server.DB(sess.db)
+		n, _ := strconv.ParseInt(db[args[1]], 10, 64)
+		n++
+		db[args[1]] = strconv.FormatInt(n, 10)
+		return intReply(n)
+	case "DEL":
+		db := sess.server.DB(sess.db)
+		var n int64
+		for _, key := range args[1:] {
+			if _, ok := db[key]; ok {
+				delete(db, key)
+				n++
+			}
+		}
+		return intReply(n)
+	case "EXISTS":
+		db := sess.server.DB(sess.db)
+		var n int64
+		for _, key := range args[1:] {
+			if _, ok := db[key]; ok {
+				n++
+			}
+		}
+		return intReply(n)
+	case "MGET":
+		db := sess.server.DB(sess.db)
+		replies := make([]string, len(args)-1)
+		for i, key := range args[1:] {
+			if v, ok := db[key]; ok {
+				replies[i] = bulkReply(v)
+			} else {
+				replies[i] = nilReply()
+			}
+		}
+		return arrayReply(replies...)
+	case "MSET", "MSETNX":
+		db := sess.server.DB(sess.db)
+		if command == "MSETNX" {
+			for i := 1; i < len(args); i += 2 {
+				if _, ok := db[args[i]]; ok {
+					return intReply(0)
+				}
+			}
+		}
+		for i := 1; i+1 < len(args); i += 2 {
+			db[args[i]] = args[i+1]
+		}
+		if command == "MSETNX" {
+			return intReply(1)
+		}
+		return simpleReply("OK")
+	case "SADD":
+		db := sess.server.DB(sess.db)
+		set := fakeSet(db[args[1]])
+		var n int64
+		for _, member := range args[2:] {
+			if !set[member] {
+				set[member] = true
+				n++
+			}
+		}
+		db[args[1]] = fakeSetValue(set)
+		return intReply(n)
+	case "SMEMBERS":
+		return fakeMembers(fakeSet(sess.server.DB(sess.db)[args[1]]))
+	case "SINTER", "SUNION", "SDIFF", "SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE":
+		db := sess.server.DB(sess.db)
+		keys := args[1:]
+		if strings.HasSuffix(command, "STORE") {
+			keys = args[2:]
+		}
+		result := fakeSet(db[keys[0]])
+		for _, key := range keys[1:] {
+			set := fakeSet(db[key])
+			for member := range set {
+				if strings.HasPrefix(command, "SUNION") {
+					result[member] = true
+				}
+			}
+			for member := range result {
+				if strings.HasPrefix(command, "SINTER") && !set[member] || strings.HasPrefix(command, "SDIFF") && set[member] {
+					delete(result, member)
+				}
+			}
+		}
+		if strings.HasSuffix(command, "STORE") {
+			db[args[1]] = fakeSetValue(result)
+			return intReply(int64(len(result)))
+		}
+		return fakeMembers(result)
+	}
+	return errReply("ERR unknown command '" + args[0] + "'")
+}
+
+// sets are kept in the string value as sorted members joined by \x00
+func fakeSet(value string) map[string]bool {
+	set := make(map[string]bool)
+	if value != "" {
+		for _, member := range strings.Split(value, "\x00") {
+			set[member] = true
+		}
+	}
+	return set
+}
+
+func fakeSetValue(set map[string]bool) string {
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return strings.Join(members, "\x00")
+}
+
+func fakeMembers(set map[string]bool) string {
+	var replies []string
+	for _, member := range strings.Split(fakeSetValue(set), "\x00") {
+		if member != "" {
+			replies = append(replies, bulkReply(member))
+		}
+	}
+	return arrayReply(replies...)
+}
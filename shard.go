@@ -0,0 +1,362 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrCrossSlot = errors.New(CommonErrPrefix + "CROSSSLOT keys in request don't hash to the same server")
+
+// keys of one request going to the same pool
+type keyGroup struct {
+	pool  *Pool
+	keys  []string
+	index []int // index of keys in the request
+}
+
+// group keys by the pool of route in the order of first seen, nil if any key has no pool
+func groupKeys(keys []string, route func(key string) *Pool) []*keyGroup {
+	var groups []*keyGroup
+	byPool := make(map[*Pool]*keyGroup)
+	for i, key := range keys {
+		pool := route(key)
+		if pool == nil {
+			return nil
+		}
+		g, ok := byPool[pool]
+		if !ok {
+			g = &keyGroup{pool: pool}
+			byPool[pool] = g
+			groups = append(groups, g)
+		}
+		g.keys = append(g.keys, key)
+		g.index = append(g.index, i)
+	}
+	return groups
+}
+
+// call fn with a conn of each group in parallel, the first error is returned
+func fanOut(ctx context.Context, groups []*keyGroup, fn func(c *Conn, g *keyGroup) error) error {
+	if len(groups) == 1 {
+		g := groups[0]
+		return g.pool.WithConn(ctx, func(c *Conn) error { return fn(c, g) })
+	}
+	errs := make([]error, len(groups))
+	var wg sync.WaitGroup
+	for i, g := range groups {
+		wg.Add(1)
+		go func(i int, g *keyGroup) {
+			defer wg.Done()
+			errs[i] = g.pool.WithConn(ctx, func(c *Conn) error { return fn(c, g) })
+		}(i, g)
+	}
+	wg.Wait()
+	for _, e := range errs {
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func keyArgs(keys []string) []interface{} {
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	return args
+}
+
+// keys grouped by the servers they go to
+func (mp *MultiPool) group(keys []string) ([]*keyGroup, error) {
+	mp.mu.RLock()
+	groups := groupKeys(keys, func(key string) *Pool { return mp.pools[mp.server(key)] })
+	mp.mu.RUnlock()
+	if groups == nil {
+		return nil, ErrNoServer
+	}
+	return groups, nil
+}
+
+// the only pool of keys, ErrCrossSlot if the keys go to more than one server
+func (mp *MultiPool) single(keys []string) (*keyGroup, error) {
+	groups, e := mp.group(keys)
+	if e != nil {
+		return nil, e
+	}
+	if len(groups) > 1 {
+		return nil, ErrCrossSlot
+	}
+	return groups[0], nil
+}
+
+// call the command of keys on each server, args of each server are built by args
+func (mp *MultiPool) callGroups(keys []string, command string, args func(g *keyGroup) []interface{}, merge func(g *keyGroup, v interface{}) error) error {
+	if len(keys) == 0 {
+		return ErrBadArgs
+	}
+	groups, e := mp.group(keys)
+	if e != nil {
+		return e
+	}
+	var mu sync.Mutex
+	return fanOut(context.Background(), groups, func(c *Conn, g *keyGroup) error {
+		v, e := c.callN(mp.opt.retryTimes, command, args(g)...)
+		if e != nil {
+			return e
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return merge(g, v)
+	})
+}
+
+// sum of the integer replies of the command on each server
+func (mp *MultiPool) sumKeys(command string, keys []string) (int64, error) {
+	var sum int64
+	e := mp.callGroups(keys, command, func(g *keyGroup) []interface{} { return keyArgs(g.keys) }, func(g *keyGroup, v interface{}) error {
+		n, ok := v.(int64)
+		if !ok {
+			return ErrResponseType
+		}
+		sum += n
+		return nil
+	})
+	if e != nil {
+		return -1, e
+	}
+	return sum, nil
+}
+
+// DEL of the keys on each server in parallel, the number of keys deleted
+func (mp *MultiPool) DELMulti(keys []string) (int64, error) {
+	return mp.sumKeys("DEL", keys)
+}
+
+// number of the keys exist, a key repeated is counted for each
+func (mp *MultiPool) EXISTSMulti(keys []string) (int64, error) {
+	return mp.sumKeys("EXISTS", keys)
+}
+
+// values in the order of keys, nil for the keys not exist
+func (mp *MultiPool) MGET(keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	e := mp.callGroups(keys, "MGET", func(g *keyGroup) []interface{} { return keyArgs(g.keys) }, func(g *keyGroup, v interface{}) error {
+		vs, ok := v.([]interface{})
+		if !ok || len(vs) != len(g.keys) {
+			return ErrResponseType
+		}
+		for i, value := range vs {
+			values[g.index[i]] = value
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return values, nil
+}
+
+// MSET on each server in parallel, not atomic across servers: some servers may be set if error returned
+func (mp *MultiPool) MSET(kv map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	e := mp.callGroups(keys, "MSET", func(g *keyGroup) []interface{} {
+		args := make([]interface{}, 0, 2*len(g.keys))
+		for _, k := range g.keys {
+			args = append(args, k, kv[k])
+		}
+		return args
+	}, func(g *keyGroup, v interface{}) error {
+		if _, ok := v.([]byte); !ok {
+			return ErrResponseType
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return []byte("OK"), nil
+}
+
+// MSETNX is atomic only on one server, ErrCrossSlot if the keys go to more than one server
+func (mp *MultiPool) MSETNX(kv map[string]string) (int64, error) {
+	keys := make([]string, 0, len(kv))
+	args := make([]interface{}, 0, 2*len(kv))
+	for k, v := range kv {
+		keys = append(keys, k)
+		args = append(args, k, v)
+	}
+	if len(keys) == 0 {
+		return -1, ErrBadArgs
+	}
+	n, e := mp.callSingle(keys, "MSETNX", args...)
+	if e != nil {
+		return -1, e
+	}
+	if _, ok := n.(int64); !ok {
+		return -1, ErrResponseType
+	}
+	return n.(int64), nil
+}
+
+// call the command on the only server of keys
+func (mp *MultiPool) callSingle(keys []string, command string, args ...interface{}) (interface{}, error) {
+	g, e := mp.single(keys)
+	if e != nil {
+		return nil, e
+	}
+	return mp.callOn(g.pool, command, args...)
+}
+
+// call the command with a conn of pool
+func (mp *MultiPool) callOn(pool *Pool, command string, args ...interface{}) (v interface{}, e error) {
+	e = pool.WithConn(context.Background(), func(c *Conn) error {
+		v, e = c.callN(mp.opt.retryTimes, command, args...)
+		return e
+	})
+	return v, e
+}
+
+// SINTER, SUNION or SDIFF, by the server if the keys on one server,
+// or computed from the members of each key pipelined to each server
+func (mp *MultiPool) setAlgebra(command string, keys []string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, ErrBadArgs
+	}
+	groups, e := mp.group(keys)
+	if e != nil {
+		return nil, e
+	}
+	if len(groups) == 1 {
+		v, e := mp.callOn(groups[0].pool, command, keyArgs(keys)...)
+		if e != nil {
+			return nil, e
+		}
+		return membersOf(v)
+	}
+
+	sets := make([][][]byte, len(keys))
+	e = fanOut(context.Background(), groups, func(c *Conn, g *keyGroup) error {
+		for _, key := range g.keys {
+			if e := c.PipeSend("SMEMBERS", key); e != nil {
+				return e
+			}
+		}
+		vs, e := c.PipeExec()
+		if e != nil {
+			return e
+		}
+		for i, v := range vs {
+			members, e := membersOf(v)
+			if e != nil {
+				return e
+			}
+			sets[g.index[i]] = members
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return combine(command, sets), nil
+}
+
+func membersOf(v interface{}) ([][]byte, error) {
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, ErrResponseType
+	}
+	members := make([][]byte, len(vs))
+	for i, value := range vs {
+		if members[i], ok = value.([]byte); !ok {
+			return nil, ErrResponseType
+		}
+	}
+	return members, nil
+}
+
+// intersection, union or difference of the first set and the others
+func combine(command string, sets [][][]byte) [][]byte {
+	var members [][]byte
+	if command == "SUNION" {
+		seen := make(map[string]bool)
+		for _, set := range sets {
+			for _, m := range set {
+				if !seen[string(m)] {
+					seen[string(m)] = true
+					members = append(members, m)
+				}
+			}
+		}
+		return members
+	}
+
+	// number of the sets each member of the first set is in
+	in := make(map[string]int, len(sets[0]))
+	for _, m := range sets[0] {
+		in[string(m)] = 1
+	}
+	for _, set := range sets[1:] {
+		seen := make(map[string]bool, len(set))
+		for _, m := range set {
+			if n, ok := in[string(m)]; ok && !seen[string(m)] {
+				seen[string(m)] = true
+				in[string(m)] = n + 1
+			}
+		}
+	}
+	for _, m := range sets[0] {
+		n := in[string(m)]
+		if command == "SINTER" && n == len(sets) || command == "SDIFF" && n == 1 {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// members of the intersection of the sets, computed by the client if the keys on more than one server
+func (mp *MultiPool) SINTER(keys []string) ([][]byte, error) {
+	return mp.setAlgebra("SINTER", keys)
+}
+
+// members of the union of the sets, computed by the client if the keys on more than one server
+func (mp *MultiPool) SUNION(keys []string) ([][]byte, error) {
+	return mp.setAlgebra("SUNION", keys)
+}
+
+// members of the first set not in the others, computed by the client if the keys on more than one server
+func (mp *MultiPool) SDIFF(keys []string) ([][]byte, error) {
+	return mp.setAlgebra("SDIFF", keys)
+}
+
+// store the result on the server, ErrCrossSlot if key and keys go to more than one server
+func (mp *MultiPool) storeSets(command, key string, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return -1, ErrBadArgs
+	}
+	all := append([]string{key}, keys...)
+	n, e := mp.callSingle(all, command, keyArgs(all)...)
+	if e != nil {
+		return -1, e
+	}
+	if _, ok := n.(int64); !ok {
+		return -1, ErrResponseType
+	}
+	return n.(int64), nil
+}
+
+func (mp *MultiPool) SINTERSTORE(key string, keys []string) (int64, error) {
+	return mp.storeSets("SINTERSTORE", key, keys)
+}
+
+func (mp *MultiPool) SUNIONSTORE(key string, keys []string) (int64, error) {
+	return mp.storeSets("SUNIONSTORE", key, keys)
+}
+
+func (mp *MultiPool) SDIFFSTORE(key string, keys []string) (int64, error) {
+	return mp.storeSets("SDIFFSTORE", key, keys)
+}
@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newShards(t *testing.T, n int) (*MultiPool, []*fakeServer) {
+	servers := make([]*fakeServer, n)
+	addrs := make([]string, n)
+	for i := range servers {
+		servers[i] = newFakeServer(t, nil)
+		addrs[i] = servers[i].Addr()
+	}
+	mp, e := NewMultiPoolOptions(addrs)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Cleanup(func() { mp.Close() })
+	return mp, servers
+}
+
+func TestShardStrings(t *testing.T) {
+	mp, servers := newShards(t, 3)
+
+	kv := make(map[string]string)
+	var keys []string
+	for i := 0; i < 30; i++ {
+		key := "key:" + strconv.Itoa(i)
+		kv[key] = "v" + strconv.Itoa(i)
+		keys = append(keys, key)
+	}
+	if v, e := mp.MSET(kv); e != nil || string(v) != "OK" {
+		t.Fatalf("MSET=%s e=%v", v, e)
+	}
+	for _, s := range servers {
+		if s.Count("MSET") != 1 {
+			t.Errorf("%s MSET %d times", s.Addr(), s.Count("MSET"))
+		}
+		for key := range s.DB(0) {
+			if mp.ServerByKey(key) != s.Addr() {
+				t.Errorf("%s set on %s", key, s.Addr())
+			}
+		}
+	}
+
+	// in the order of keys, missing keys are nil
+	get := append([]string{"missing"}, keys...)
+	get = append(get, keys[3])
+	values, e := mp.MGET(get)
+	if e != nil || len(values) != len(get) {
+		t.Fatalf("MGET=%v e=%v", values, e)
+	}
+	if values[0] != nil {
+		t.Errorf("missing=%v", values[0])
+	}
+	for i, key := range get[1:] {
+		if v, ok := values[i+1].([]byte); !ok || string(v) != kv[key] {
+			t.Errorf("%s=%v", key, values[i+1])
+		}
+	}
+
+	if n, e := mp.EXISTSMulti(get); e != nil || n != 31 {
+		t.Errorf("EXISTS=%d e=%v", n, e)
+	}
+	if n, e := mp.DELMulti(append([]string{"missing"}, keys[:10]...)); e != nil || n != 10 {
+		t.Errorf("DEL=%d e=%v", n, e)
+	}
+	if n, e := mp.EXISTSMulti(keys); e != nil || n != 20 {
+		t.Errorf("EXISTS=%d e=%v", n, e)
+	}
+	if _, e := mp.MGET(nil); e != ErrBadArgs {
+		t.Errorf("e=%v", e)
+	}
+
+	// MSETNX is not split
+	if _, e := mp.MSETNX(kv); e != ErrCrossSlot {
+		t.Errorf("MSETNX e=%v", e)
+	}
+	tagged := map[string]string{"user:{42}:profile": "p", "user:{42}:cart": "c"}
+	if n, e := mp.MSETNX(tagged); e != nil || n != 1 {
+		t.Errorf("MSETNX=%d e=%v", n, e)
+	}
+	if n, e := mp.MSETNX(tagged); e != nil || n != 0 {
+		t.Errorf("MSETNX=%d e=%v", n, e)
+	}
+}
+
+func TestShardSets(t *testing.T) {
+	mp, servers := newShards(t, 3)
+
+	sets := map[string][]string{
+		"a": {"1", "2", "3", "4"},
+		"b": {"2", "3", "5"},
+		"c": {"3", "4", "6"},
+	}
+	for i := 0; i < 20; i++ {
+		sets["s"+strconv.Itoa(i)] = []string{"x", strconv.Itoa(i)}
+	}
+	for key, members := range sets {
+		e := mp.PoolByKey(key).WithConn(context.Background(), func(c *Conn) error {
+			_, e := c.Call("SADD", append([]interface{}{key}, keyArgs(members)...)...)
+			return e
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	keys := []string{"a", "b", "c"}
+	for i := 0; i < 20; i++ {
+		keys = append(keys, "s"+strconv.Itoa(i))
+	}
+	if groups, _ := mp.group(keys); len(groups) != len(servers) {
+		t.Fatalf("keys on %d servers", len(groups))
+	}
+
+	sorted := func(members [][]byte, e error) string {
+		if e != nil {
+			return e.Error()
+		}
+		s := make([]string, len(members))
+		for i, m := range members {
+			s[i] = string(m)
+		}
+		sort.Strings(s)
+		return strings.Join(s, ",")
+	}
+	cases := []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"SINTER", sorted(mp.SINTER(keys[:3])), []string{"3"}},
+		{"SUNION", sorted(mp.SUNION(keys[:3])), []string{"1", "2", "3", "4", "5", "6"}},
+		{"SDIFF", sorted(mp.SDIFF(keys[:3])), []string{"1"}},
+		{"SINTER all", sorted(mp.SINTER(keys[3:])), []string{"x"}},
+		{"SDIFF missing", sorted(mp.SDIFF([]string{"a", "missing"})), []string{"1", "2", "3", "4"}},
+	}
+	for _, c := range cases {
+		if want := strings.Join(c.want, ","); c.got != want {
+			t.Errorf("%s=%s want %s", c.name, c.got, want)
+		}
+	}
+	for _, s := range servers {
+		if s.Count("SMEMBERS") == 0 {
+			t.Errorf("%s no SMEMBERS", s.Addr())
+		}
+	}
+
+	// the store commands can not be split
+	if _, e := mp.SINTERSTORE("dest", keys); e != ErrCrossSlot {
+		t.Errorf("SINTERSTORE e=%v", e)
+	}
+	for _, key := range []string{"{t}a", "{t}b"} {
+		mp.PoolByKey(key).WithConn(context.Background(), func(c *Conn) error {
+			_, e := c.Call("SADD", key, "1", key)
+			return e
+		})
+	}
+	if n, e := mp.SUNIONSTORE("{t}dest", []string{"{t}a", "{t}b"}); e != nil || n != 3 {
+		t.Errorf("SUNIONSTORE=%d e=%v", n, e)
+	}
+	if members, e := mp.SINTER([]string{"{t}a", "{t}b"}); e != nil || len(members) != 1 || string(members[0]) != "1" {
+		t.Errorf("SINTER=%q e=%v", members, e)
+	}
+}
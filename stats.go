@@ -0,0 +1,209 @@
+package redis
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats counters of one pool, updated by atomic without the lock of pool
+type PoolStats struct {
+	create       int64
+	createFailed int64
+	waitTimeout  int64
+	wait         int64 // times of waiting for a conn
+	waitDuration int64 // total nanoseconds of waiting
+	evict        int64 // idle conns closed by the maintain loop
+	expire       int64 // conns closed beyond the max lifetime
+	leak         int64 // conns held too long or garbage collected without pushed back
+	close        int64 // conns closed
+	connAge      int64 // total nanoseconds from dial to close of the closed conns
+	pingErr      int64
+	callNetErr   int64
+	call         int64
+	meter        meter
+
+	latency   map[string]*histogram // command => latency
+	latencyMu sync.RWMutex
+
+	mu   sync.Mutex
+	last PoolInfo // snapshot of the last Delta
+}
+
+type PoolInfo struct {
+	Address         string
+	IdleNum         int
+	ActiveNum       int
+	CreateNum       int
+	TimeoutNum      int
+	WaitNum         int
+	WaitDuration    time.Duration
+	EvictNum        int
+	ExpireNum       int
+	LeakNum         int
+	CloseNum        int
+	ConnAge         time.Duration // total lifetime of the closed conns
+	CreateFailedNum int
+	CallNetErrNum   int
+	PingErrNum      int
+	CallNum         int64
+	Qps             int64   // calls of the last second
+	Qps10           float64 // calls per second of the last 10 seconds
+	Qps60           float64
+	ErrQps          int64 // failed calls of the last second
+	ErrQps10        float64
+	ErrQps60        float64
+	Latency         map[string]*Latency // command => latency since the pool created
+}
+
+const meterSeconds = 64 // more than 60 seconds and the current second
+
+// meter count the calls and errors of each second in the last minute
+type meter struct {
+	mu      sync.Mutex
+	buckets [meterSeconds]bucket
+}
+
+type bucket struct {
+	sec   int64 // unix second of the counts
+	calls int64
+	errs  int64
+}
+
+// a call finished at the unix second now
+func (m *meter) mark(now int64, failed bool) {
+	m.mu.Lock()
+	b := &m.buckets[now%meterSeconds]
+	if b.sec != now {
+		*b = bucket{sec: now}
+	}
+	b.calls++
+	if failed {
+		b.errs++
+	}
+	m.mu.Unlock()
+}
+
+// calls and errors per second of the complete seconds before now
+func (m *meter) rate(now int64, seconds int64) (calls, errs float64) {
+	m.mu.Lock()
+	for sec := now - seconds; sec < now; sec++ {
+		if b := &m.buckets[sec%meterSeconds]; b.sec == sec {
+			calls += float64(b.calls)
+			errs += float64(b.errs)
+		}
+	}
+	m.mu.Unlock()
+	return calls / float64(seconds), errs / float64(seconds)
+}
+
+func (m *meter) reset() {
+	m.mu.Lock()
+	m.buckets = [meterSeconds]bucket{}
+	m.mu.Unlock()
+}
+
+func (s *PoolStats) addWait(d time.Duration) {
+	atomic.AddInt64(&s.wait, 1)
+	atomic.AddInt64(&s.waitDuration, int64(d))
+}
+
+// a conn of the pool is closed after age
+func (s *PoolStats) addClose(age time.Duration) {
+	atomic.AddInt64(&s.close, 1)
+	atomic.AddInt64(&s.connAge, int64(age))
+}
+
+// fill the counters into info
+func (s *PoolStats) load(info *PoolInfo) {
+	info.CreateNum = int(atomic.LoadInt64(&s.create))
+	info.CreateFailedNum = int(atomic.LoadInt64(&s.createFailed))
+	info.TimeoutNum = int(atomic.LoadInt64(&s.waitTimeout))
+	info.WaitNum = int(atomic.LoadInt64(&s.wait))
+	info.WaitDuration = time.Duration(atomic.LoadInt64(&s.waitDuration))
+	info.EvictNum = int(atomic.LoadInt64(&s.evict))
+	info.ExpireNum = int(atomic.LoadInt64(&s.expire))
+	info.LeakNum = int(atomic.LoadInt64(&s.leak))
+	info.CloseNum = int(atomic.LoadInt64(&s.close))
+	info.ConnAge = time.Duration(atomic.LoadInt64(&s.connAge))
+	info.PingErrNum = int(atomic.LoadInt64(&s.pingErr))
+	info.CallNetErrNum = int(atomic.LoadInt64(&s.callNetErr))
+	info.CallNum = atomic.LoadInt64(&s.call)
+
+	now := time.Now().Unix()
+	calls, errs := s.meter.rate(now, 1)
+	info.Qps, info.ErrQps = int64(calls), int64(errs)
+	info.Qps10, info.ErrQps10 = s.meter.rate(now, 10)
+	info.Qps60, info.ErrQps60 = s.meter.rate(now, 60)
+	info.Latency = s.latencies()
+}
+
+func (s *PoolStats) reset() {
+	for _, n := range []*int64{&s.create, &s.createFailed, &s.waitTimeout, &s.wait, &s.waitDuration,
+		&s.evict, &s.expire, &s.leak, &s.close, &s.connAge, &s.pingErr, &s.callNetErr, &s.call} {
+		atomic.StoreInt64(n, 0)
+	}
+	s.meter.reset()
+	s.latencyMu.Lock()
+	s.latency = nil
+	s.latencyMu.Unlock()
+	s.mu.Lock()
+	s.last = PoolInfo{}
+	s.mu.Unlock()
+}
+
+// counters of info minus the counters of prev, the gauges like IdleNum, ActiveNum, the rates and Latency are kept
+func (info *PoolInfo) Sub(prev *PoolInfo) *PoolInfo {
+	delta := *info
+	delta.CreateNum -= prev.CreateNum
+	delta.CreateFailedNum -= prev.CreateFailedNum
+	delta.TimeoutNum -= prev.TimeoutNum
+	delta.WaitNum -= prev.WaitNum
+	delta.WaitDuration -= prev.WaitDuration
+	delta.EvictNum -= prev.EvictNum
+	delta.ExpireNum -= prev.ExpireNum
+	delta.LeakNum -= prev.LeakNum
+	delta.CloseNum -= prev.CloseNum
+	delta.ConnAge -= prev.ConnAge
+	delta.PingErrNum -= prev.PingErrNum
+	delta.CallNetErrNum -= prev.CallNetErrNum
+	delta.CallNum -= prev.CallNum
+	return &delta
+}
+
+// average wait time of the waits, zero if no wait
+func (info *PoolInfo) AvgWait() time.Duration {
+	if info.WaitNum == 0 {
+		return 0
+	}
+	return info.WaitDuration / time.Duration(info.WaitNum)
+}
+
+// average lifetime of the closed conns, zero if no conn closed
+func (info *PoolInfo) AvgConnAge() time.Duration {
+	if info.CloseNum == 0 {
+		return 0
+	}
+	return info.ConnAge / time.Duration(info.CloseNum)
+}
+
+// the stats of pool in a snapshot, the gauges and counters are read under the lock of pool
+func (p *Pool) Snapshot() *PoolInfo {
+	info := &PoolInfo{Address: p.Address}
+	p.mu.RLock()
+	info.IdleNum = p.IdleNum
+	info.ActiveNum = p.ActiveNum
+	p.stats.load(info)
+	p.mu.RUnlock()
+	return info
+}
+
+// the counters since the last Delta, or since the pool created for the first call
+func (p *Pool) Delta() *PoolInfo {
+	info := p.Snapshot()
+	p.stats.mu.Lock()
+	delta := info.Sub(&p.stats.last)
+	p.stats.last = *info
+	p.stats.mu.Unlock()
+	return delta
+}
@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPoolSnapshot(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://"+s.Addr(), WithMaxConnPerServer(1), WithWaitTimeout(20*time.Millisecond))
+
+	c := p.Pop()
+	if _, e := c.Call("SET", "a", "1"); e != nil {
+		t.Fatal(e)
+	}
+	if _, e := p.PopContext(context.Background()); e != ErrWaitTimeout {
+		t.Fatalf("e=%v", e)
+	}
+	time.Sleep(10 * time.Millisecond)
+	p.Push(c)
+	p.Close()
+
+	// SET and QUIT
+	info := p.Snapshot()
+	if info.Address != s.Addr() || info.CreateNum != 1 || info.CallNum != 2 || info.IdleNum != 0 || info.ActiveNum != 0 {
+		t.Errorf("info=%+v", info)
+	}
+	if info.WaitNum != 1 || info.TimeoutNum != 1 || info.AvgWait() < 20*time.Millisecond {
+		t.Errorf("wait=%d timeout=%d avg=%v", info.WaitNum, info.TimeoutNum, info.AvgWait())
+	}
+	if info.CloseNum != 1 || info.AvgConnAge() < 30*time.Millisecond {
+		t.Errorf("close=%d age=%v", info.CloseNum, info.AvgConnAge())
+	}
+
+	// closed once
+	c.Close()
+	if n := p.Snapshot().CloseNum; n != 1 {
+		t.Errorf("close=%d", n)
+	}
+}
+
+func TestPoolDelta(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://" + s.Addr())
+
+	for i := 0; i < 3; i++ {
+		c := p.Pop()
+		c.Call("INCR", "a")
+		p.Push(c)
+	}
+	if d := p.Delta(); d.CallNum != 3 || d.CreateNum != 1 || d.IdleNum != 1 {
+		t.Errorf("delta=%+v", d)
+	}
+
+	c := p.Pop()
+	c.Call("INCR", "a")
+	if d := p.Delta(); d.CallNum != 1 || d.CreateNum != 0 || d.ActiveNum != 1 {
+		t.Errorf("delta=%+v", d)
+	}
+	p.Push(c)
+	if d := p.Delta(); d.CallNum != 0 || d.IdleNum != 1 {
+		t.Errorf("delta=%+v", d)
+	}
+	if info := p.Snapshot(); info.CallNum != 4 {
+		t.Errorf("delta should not clear the counters, call=%d", info.CallNum)
+	}
+}
+
+func TestMeter(t *testing.T) {
+	var m meter
+	const now = 10000
+	for sec := int64(now - 70); sec <= now; sec++ {
+		// one call each second, and one more failed call in the last 10 seconds
+		m.mark(sec, false)
+		if sec >= now-10 {
+			m.mark(sec, true)
+		}
+	}
+	if calls, errs := m.rate(now, 1); calls != 2 || errs != 1 {
+		t.Errorf("1s calls=%v errs=%v", calls, errs)
+	}
+	if calls, errs := m.rate(now, 10); calls != 2 || errs != 1 {
+		t.Errorf("10s calls=%v errs=%v", calls, errs)
+	}
+	if calls, errs := m.rate(now, 60); calls != 70.0/60 || errs != 10.0/60 {
+		t.Errorf("60s calls=%v errs=%v", calls, errs)
+	}
+	// no call in the last 5 seconds
+	if calls, errs := m.rate(now+6, 10); calls != 1 || errs != 0.5 {
+		t.Errorf("calls=%v errs=%v", calls, errs)
+	}
+}
+
+func TestPoolInfoRate(t *testing.T) {
+	s := newFakeServer(t, nil)
+	p, _ := NewPoolURL("redis://" + s.Addr())
+	c := p.Pop()
+	defer p.Push(c)
+
+	// wait the calls to be a complete second
+	now := time.Now().Unix()
+	for i := 0; i < 5; i++ {
+		c.Call("INCR", "a")
+	}
+	c.Call("NOSUCH")
+	if time.Now().Unix() != now {
+		t.Skip("calls across seconds")
+	}
+	for time.Now().Unix() == now {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start := time.Now()
+	info := p.Info()
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("Info should not block, took %v", d)
+	}
+	if time.Now().Unix() != now+1 {
+		t.Skip("too slow to check the rate of the last second")
+	}
+	if info.Qps != 6 || info.ErrQps != 1 || info.Qps10 != 0.6 || info.ErrQps60 != 1.0/60 {
+		t.Errorf("info=%+v", info)
+	}
+	if p.QPS() != 6 {
+		t.Errorf("QPS=%d", p.QPS())
+	}
+}
@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// self-signed certificate for 127.0.0.1 and redis.local, used by both server and client
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if e != nil {
+		t.Fatal(e)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis.local"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"redis.local"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, e := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if e != nil {
+		t.Fatal(e)
+	}
+	cert, e := x509.ParseCertificate(der)
+	if e != nil {
+		t.Fatal(e)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, roots
+}
+
+func newTLSServer(t *testing.T, config *tls.Config) *fakeServer {
+	ln, e := tls.Listen("tcp", "127.0.0.1:0", config)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return serveFake(t, ln, nil)
+}
+
+func TestTLS(t *testing.T) {
+	cert, roots := selfSignedCert(t)
+	s := newTLSServer(t, &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	client, e := NewClient([]string{"rediss://" + s.Addr()}, WithTLSConfig(&tls.Config{RootCAs: roots}))
+	if e != nil {
+		t.Fatal(e)
+	}
+	cd := client.Driver("")
+	if _, e := cd.SET("key", "value"); e != nil {
+		t.Fatal(e)
+	}
+	v, e := cd.GET("key")
+	if e != nil || string(v) != "value" {
+		t.Fatalf("GET=%s e=%v", v, e)
+	}
+	if p := cd.mp; p.Idles() != 1 || p.Snapshot().CreateNum != 1 {
+		t.Errorf("idle=%d create=%d", p.Idles(), p.Snapshot().CreateNum)
+	}
+
+	// the certificate is not trusted
+	if _, e := DialURL("rediss://" + s.Addr()); e == nil {
+		t.Error("unknown authority should fail")
+	}
+	// verify the server name instead of ip
+	c, e := DialURL("redis://"+s.Addr(), WithTLSConfig(&tls.Config{RootCAs: roots}), WithTLSServerName("redis.local"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	c.Close()
+	if _, e := DialURL("redis://"+s.Addr(), WithTLSConfig(&tls.Config{RootCAs: roots}), WithTLSServerName("other.local")); e == nil {
+		t.Error("wrong server name should fail")
+	}
+	// the server name is kept if set before the config
+	if _, e := DialURL("redis://"+s.Addr(), WithTLSServerName("other.local"), WithTLSConfig(&tls.Config{RootCAs: roots})); e == nil {
+		t.Error("server name set before config is dropped")
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	cert, roots := selfSignedCert(t)
+	s := newTLSServer(t, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    roots,
+	})
+
+	config := &tls.Config{RootCAs: roots}
+	for _, opts := range [][]Option{
+		{WithTLSConfig(config), WithTLSCertificate(cert)},
+		{WithTLSCertificate(cert), WithTLSConfig(config)},
+	} {
+		c, e := DialURL("redis://"+s.Addr(), opts...)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !c.IsAlive() {
+			t.Error("PING failed")
+		}
+		c.Close()
+	}
+	if len(config.Certificates) != 0 {
+		t.Error("config of caller should not be modified")
+	}
+
+	// handshake of tls 1.3 finishes before the server verifies the client certificate,
+	// the failure is reported by the first command
+	c, e := DialURL("redis://"+s.Addr(), WithTLSConfig(config))
+	if e == nil {
+		defer c.Close()
+		if c.IsAlive() {
+			t.Error("conn without client certificate should fail")
+		}
+	}
+}